@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Denylist keeps the IDs of the json web tokens invalidated before their expiration, e.g. on logout.
+type Denylist interface {
+	// Add denies the token ID until the expiration of the token, after that the token is rejected by its own claims.
+	Add(ctx context.Context, id string, expiration time.Time) error
+	// Contains reports whether the token ID was denied.
+	Contains(ctx context.Context, id string) (bool, error)
+}
+
+type memoryDenylist struct {
+	mu  sync.Mutex
+	ids map[string]time.Time
+	now func() time.Time
+}
+
+// NewMemoryDenylist returns a Denylist that keeps the token IDs in memory.
+func NewMemoryDenylist() Denylist {
+	return &memoryDenylist{
+		ids: make(map[string]time.Time),
+		now: time.Now,
+	}
+}
+
+func (m *memoryDenylist) Add(_ context.Context, id string, expiration time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// expired tokens are rejected anyway, there is no need to keep their IDs.
+	now := m.now()
+	for i, exp := range m.ids {
+		if now.After(exp) {
+			delete(m.ids, i)
+		}
+	}
+
+	m.ids[id] = expiration
+	return nil
+}
+
+func (m *memoryDenylist) Contains(_ context.Context, id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.ids[id]
+	return ok, nil
+}
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryDenylist(t *testing.T) {
+	t.Run("success - denied ids are found.", func(t *testing.T) {
+		denylist := NewMemoryDenylist()
+		assert.Nil(t, denylist.Add(context.Background(), "jwt-1", time.Now().Add(time.Hour)))
+
+		denied, err := denylist.Contains(context.Background(), "jwt-1")
+		assert.Nil(t, err)
+		assert.True(t, denied)
+
+		denied, err = denylist.Contains(context.Background(), "jwt-2")
+		assert.Nil(t, err)
+		assert.False(t, denied)
+	})
+
+	t.Run("success - expired ids are dropped when adding.", func(t *testing.T) {
+		now := time.Now()
+		denylist := &memoryDenylist{
+			ids: make(map[string]time.Time),
+			now: func() time.Time { return now },
+		}
+		assert.Nil(t, denylist.Add(context.Background(), "jwt-1", now.Add(time.Hour)))
+
+		now = now.Add(time.Hour + time.Second)
+		assert.Nil(t, denylist.Add(context.Background(), "jwt-2", now.Add(time.Hour)))
+		assert.Len(t, denylist.ids, 1)
+	})
+}
@@ -10,6 +10,8 @@ type Endpoints struct {
 	LogoutEndpoint           endpoint.Endpoint
 	GetOauthUrlEndpoint      endpoint.Endpoint
 	GetOauthCallbackEndpoint endpoint.Endpoint
+	RefreshEndpoint          endpoint.Endpoint
+	GetJWKSEndpoint          endpoint.Endpoint
 }
 
 func MakeEndpoints(s Service) Endpoints {
@@ -17,59 +19,122 @@ func MakeEndpoints(s Service) Endpoints {
 		LogoutEndpoint:           MakeLogoutEndpoint(s),
 		GetOauthUrlEndpoint:      MakeGetOauthUrlEndpoint(s),
 		GetOauthCallbackEndpoint: MakeGetOauthCallbackEndpoint(s),
+		RefreshEndpoint:          MakeRefreshEndpoint(s),
+		GetJWKSEndpoint:          MakeGetJWKSEndpoint(s),
 	}
 }
 
 func MakeLogoutEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(logoutRequest)
+		if err := s.Logout(ctx, req.JWT); err != nil {
+			return logoutResponse{Err: err}, nil
+		}
 		return logoutResponse{}, nil
 	}
 }
 
 func MakeGetOauthUrlEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
-		_ = request.(loginRequest)
-		url, err := s.GetOauthUrl(ctx)
+		req := request.(loginRequest)
+		url, state, err := s.GetOauthUrl(ctx, req.JSON)
 		if err != nil {
 			return nil, err
 		}
-		return loginResponse{AuthUrl: url}, nil
+		return loginResponse{AuthUrl: url, State: state, JSON: req.JSON}, nil
 	}
 }
 
 func MakeGetOauthCallbackEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(callbackRequest)
-		user, err := s.ConfigGmailServiceUser(ctx, req.Code)
+		verifier, err := s.ValidateOauthState(ctx, req.State, req.BrowserState, req.JSON)
+		if err != nil {
+			return callbackResponse{Err: err, JSON: req.JSON}, nil
+		}
+		user, err := s.ConfigGmailServiceUser(ctx, req.Code, verifier)
 		if err != nil {
-			return callbackResponse{Err: err}, nil
+			return callbackResponse{Err: err, JSON: req.JSON}, nil
 		}
-		jwt, err := s.CreateJWT(ctx, user)
+		tokens, err := s.CreateSession(ctx, user)
 		if err != nil {
-			return callbackResponse{Err: err}, nil
+			return callbackResponse{Err: err, JSON: req.JSON}, nil
 		}
-		return callbackResponse{JWT: jwt}, nil
+		return callbackResponse{Tokens: tokens, JSON: req.JSON}, nil
+	}
+}
+
+func MakeRefreshEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(refreshRequest)
+		tokens, err := s.RefreshSession(ctx, req.RefreshToken)
+		if err != nil {
+			return refreshResponse{Err: err, JSON: req.JSON}, nil
+		}
+		return refreshResponse{Tokens: tokens, JSON: req.JSON}, nil
+	}
+}
+
+func MakeGetJWKSEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		return s.GetJWKS(ctx), nil
 	}
 }
 
-type logoutResponse struct{}
-type logoutRequest struct{}
+type jwksRequest struct{}
 
-type loginRequest struct{}
+type logoutRequest struct {
+	JWT string
+}
+
+type logoutResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (l logoutResponse) error() error {
+	return l.Err
+}
+
+type loginRequest struct {
+	// JSON starts a native login, its state is returned in the body instead of a cookie.
+	JSON bool
+}
 type loginResponse struct {
 	AuthUrl string `json:"auth_url"`
+	State   string `json:"state,omitempty"`
+	JSON    bool   `json:"-"`
 }
 
 type callbackRequest struct {
 	State string
 	Code  string
+	// BrowserState is the state stored in the cookie of the browser that requested the login.
+	BrowserState string
+	// JSON returns the tokens in the body instead of redirecting, native clients ask for it with `Accept: application/json`.
+	JSON bool
 }
 
 type callbackResponse struct {
-	JWT string `json:"-"`
-	Err error  `json:"error,omitempty"`
+	Tokens *SessionTokens `json:"-"`
+	JSON   bool           `json:"-"`
+	Err    error          `json:"error,omitempty"`
 }
 
 func (c callbackResponse) error() error {
 	return c.Err
 }
+
+type refreshRequest struct {
+	RefreshToken string
+	JSON         bool
+}
+
+type refreshResponse struct {
+	Tokens *SessionTokens `json:"-"`
+	JSON   bool           `json:"-"`
+	Err    error          `json:"error,omitempty"`
+}
+
+func (r refreshResponse) error() error {
+	return r.Err
+}
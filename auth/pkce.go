@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+
+	"golang.org/x/oauth2"
+)
+
+// verifierLength is the number of random bytes of a code verifier, 32 bytes encode to the 43 characters RFC 7636 asks for.
+const verifierLength = 32
+
+// newCodeVerifier generates a high entropy PKCE code verifier.
+func newCodeVerifier() (string, error) {
+	b := make([]byte, verifierLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// challengeOptions returns the authorization url parameters with the S256 challenge of the verifier.
+func challengeOptions(verifier string) []oauth2.AuthCodeOption {
+	sum := sha256.Sum256([]byte(verifier))
+	return []oauth2.AuthCodeOption{
+		oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(sum[:])),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	}
+}
+
+// verifierOption returns the token exchange parameter that proves the client started the login.
+func verifierOption(verifier string) oauth2.AuthCodeOption {
+	return oauth2.SetAuthURLParam("code_verifier", verifier)
+}
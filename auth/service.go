@@ -2,10 +2,14 @@ package auth
 
 import (
 	"context"
+	"crypto/subtle"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/go-kit/log"
@@ -14,26 +18,50 @@ import (
 
 	"github.com/orlandorode97/mailx-google-service"
 	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/keyring"
 	"github.com/orlandorode97/mailx-google-service/pkg/models"
 	"github.com/orlandorode97/mailx-google-service/pkg/repos"
 	"github.com/spf13/viper"
 	"golang.org/x/oauth2"
 )
 
+const (
+	// defaultRevokeUrl is the google endpoint that revokes the grant of an access or refresh token.
+	defaultRevokeUrl = "https://oauth2.googleapis.com/revoke"
+	// ScopeMailbox grants access to the mailbox of the user, it is issued to every session.
+	ScopeMailbox = "mailbox"
+)
+
 type MailxClaims struct {
 	ID string
+	// SessionID is the session family the json web token was issued for.
+	SessionID string `json:"sid,omitempty"`
+	// Scope is the space separated list of scopes granted to the json web token.
+	Scope string `json:"scope,omitempty"`
 	jwt.StandardClaims
 }
 
 type Service interface {
-	// Creates the oath authorization URL
-	GetOauthUrl(context.Context) (string, error)
-	// Generates the token access after a successful sign in
-	GenerateOauthToken(context.Context, string) (*oauth2.Token, error)
+	// Creates the oath authorization URL, it returns the url and the state that must come back in the callback.
+	// The state of a native login is not bound to a browser cookie, the native client sends it back itself.
+	GetOauthUrl(ctx context.Context, native bool) (string, string, error)
+	// ValidateOauthState checks the callback state, each state is valid only once. A browser login must present
+	// the state stored in its cookie, a native login is bound by the single use state and the PKCE code verifier.
+	// It returns the PKCE code verifier generated along with the state.
+	ValidateOauthState(ctx context.Context, state string, browserState string, native bool) (string, error)
+	// Generates the token access after a successful sign in, the code is exchanged along with its PKCE code verifier.
+	GenerateOauthToken(context.Context, string, string) (*oauth2.Token, error)
 	// Configuration of a gmail service for a current user
-	ConfigGmailServiceUser(context.Context, string) (*models.User, error)
-	// CreateJWT generates a json web token for mailx-google-service authentication.
-	CreateJWT(context.Context, *models.User) (string, error)
+	ConfigGmailServiceUser(context.Context, string, string) (*models.User, error)
+	// CreateSession signs in the user, it returns a short lived json web token and the refresh token of a new session.
+	CreateSession(context.Context, *models.User) (*SessionTokens, error)
+	// RefreshSession rotates the refresh token and returns a new json web token and refresh token pair.
+	// Reusing a rotated refresh token revokes the whole session family.
+	RefreshSession(context.Context, string) (*SessionTokens, error)
+	// GetJWKS returns the public keys that verify the json web tokens.
+	GetJWKS(context.Context) keyring.JSONWebKeySet
+	// Logout revokes the google grant of the user owning the json web token and invalidates the json web token.
+	Logout(context.Context, string) error
 }
 
 type service struct {
@@ -42,31 +70,83 @@ type service struct {
 	config       google.OAuthConfiguration
 	client       *http.Client
 	mailxService mailx.Service
+	states       StateStore
+	denylist     Denylist
+	keys         *keyring.KeyRing
+	// revokeUrl is the google revocation endpoint, it is replaced by a local stub in tests.
+	revokeUrl string
 }
 
-// New creates a new Auth Service.
-func New(logger log.Logger, config google.OAuthConfiguration, repo repos.Repository, mailx mailx.Service) Service {
+// New creates a new Auth Service. The denylist and the key ring must be shared with the authentication middleware.
+func New(logger log.Logger, config google.OAuthConfiguration, repo repos.Repository, mailx mailx.Service, denylist Denylist, keys *keyring.KeyRing) Service {
+	revokeUrl := viper.GetString("GOOGLE_REVOKE_URL")
+	if revokeUrl == "" {
+		revokeUrl = defaultRevokeUrl
+	}
+
 	return &service{
 		logger:       logger,
 		config:       config,
 		repo:         repo,
 		client:       http.DefaultClient,
 		mailxService: mailx,
+		states:       NewMemoryStateStore(),
+		denylist:     denylist,
+		keys:         keys,
+		revokeUrl:    revokeUrl,
 	}
 }
 
-func (s *service) GetOauthUrl(_ context.Context) (string, error) {
+func (s *service) GetOauthUrl(ctx context.Context, native bool) (string, string, error) {
 	state := uuid.NewString()
+	verifier, err := newCodeVerifier()
+	if err != nil {
+		s.logger.Log(
+			"message", "could not generate pkce code verifier",
+			"err", err.Error(),
+			"severity", "ERROR",
+		)
+		return "", "", err
+	}
 
-	url := s.config.AuthCodeURL(state, oauth2.AccessTypeOffline)
+	opts := append([]oauth2.AuthCodeOption{oauth2.AccessTypeOffline}, challengeOptions(verifier)...)
+	url := s.config.AuthCodeURL(state, opts...)
 	if url == "" {
-		return "", models.ErrAuthUrl{}
+		return "", "", models.ErrAuthUrl{}
 	}
-	return url, nil
+
+	if err := s.states.Save(ctx, state, LoginSession{Verifier: verifier, Native: native}, time.Now().Add(stateTTL)); err != nil {
+		s.logger.Log(
+			"message", "could not save oauth state",
+			"err", err.Error(),
+			"severity", "ERROR",
+		)
+		return "", "", err
+	}
+	return url, state, nil
 }
 
-func (s *service) GenerateOauthToken(_ context.Context, code string) (*oauth2.Token, error) {
-	token, err := s.config.Exchange(context.Background(), code)
+func (s *service) ValidateOauthState(ctx context.Context, state string, browserState string, native bool) (string, error) {
+	if state == "" {
+		return "", models.ErrInvalidState{}
+	}
+	if !native && subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return "", models.ErrInvalidState{}
+	}
+
+	session, err := s.states.Consume(ctx, state)
+	if err != nil {
+		return "", err
+	}
+	// a browser state can not skip its cookie check by asking for json, nor a native state be used by a browser.
+	if session.Native != native {
+		return "", models.ErrInvalidState{}
+	}
+	return session.Verifier, nil
+}
+
+func (s *service) GenerateOauthToken(ctx context.Context, code string, verifier string) (*oauth2.Token, error) {
+	token, err := s.config.Exchange(ctx, code, verifierOption(verifier))
 	if err != nil {
 		s.logger.Log(
 			"message", "could not create oauth2 token",
@@ -78,39 +158,175 @@ func (s *service) GenerateOauthToken(_ context.Context, code string) (*oauth2.To
 	return token, nil
 }
 
-func (s *service) ConfigGmailServiceUser(ctx context.Context, code string) (*models.User, error) {
-	token, err := s.GenerateOauthToken(ctx, code)
+func (s *service) ConfigGmailServiceUser(ctx context.Context, code string, verifier string) (*models.User, error) {
+	token, err := s.GenerateOauthToken(ctx, code, verifier)
 	if err != nil {
 		return nil, err
 	}
 
-	svc, err := s.mailxService.CreateGmailService(token)
+	user, err := s.createUser(ctx, token)
 	if err != nil {
 		return nil, err
 	}
 
-	user, err := s.createUser(ctx, token)
-	if err != nil {
+	if err := s.saveAccessToken(ctx, user.ID, token); err != nil {
 		return nil, err
 	}
 
-	if err := s.saveAccessToken(ctx, user.ID, token); err != nil {
+	svc, err := s.mailxService.CreateGmailService(ctx, user.ID, token)
+	if err != nil {
 		return nil, err
 	}
 	s.mailxService.AddGmailServiceByID(user.ID, svc)
 	return user, nil
 }
 
-func (s *service) CreateJWT(_ context.Context, user *models.User) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, MailxClaims{
-		ID: user.ID,
+func (s *service) GetJWKS(_ context.Context) keyring.JSONWebKeySet {
+	return s.keys.JWKS()
+}
+
+// createJWT generates a json web token for mailx-google-service authentication issued at issuedAt,
+// it returns the token and its expiration.
+func (s *service) createJWT(userID string, sessionID string, issuedAt time.Time) (string, time.Time, error) {
+	expiresAt := issuedAt.Add(accessTokenTTL)
+	token, err := s.keys.Sign(MailxClaims{
+		ID:        userID,
+		SessionID: sessionID,
+		Scope:     ScopeMailbox,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Hour * 24).Unix(),
-			IssuedAt:  jwt.TimeFunc().Unix(),
+			Id:        uuid.NewString(),
+			ExpiresAt: expiresAt.Unix(),
+			IssuedAt:  issuedAt.Unix(),
 		},
 	})
+	return token, expiresAt, err
+}
+
+// ParseJWT verifies a json web token created by the auth service with a key of the key ring and returns its claims.
+func ParseJWT(keys *keyring.KeyRing, value string) (*MailxClaims, error) {
+	return parseJWT(&jwt.Parser{}, keys, value)
+}
+
+func parseJWT(parser *jwt.Parser, keys *keyring.KeyRing, value string) (*MailxClaims, error) {
+	token, err := parser.ParseWithClaims(value, &MailxClaims{}, keys.Keyfunc)
+	if err == nil {
+		return token.Claims.(*MailxClaims), nil
+	}
+
+	e, ok := err.(*jwt.ValidationError)
+	if !ok {
+		return nil, models.ErrInvalidToken{}
+	}
+
+	switch {
+	case e.Errors&(jwt.ValidationErrorSignatureInvalid|jwt.ValidationErrorUnverifiable) != 0:
+		return nil, models.ErrInvalidSignature{}
+	case e.Errors&jwt.ValidationErrorMalformed != 0:
+		return nil, models.ErrMalformedToken{}
+	case e.Errors&jwt.ValidationErrorNotValidYet != 0:
+		return nil, models.ErrInactiveToken{}
+	case e.Errors&jwt.ValidationErrorExpired != 0:
+		return nil, models.ErrExpiredToken{}
+	case e.Inner != nil:
+		return nil, e.Inner
+	}
+	return nil, models.ErrInvalidToken{}
+}
+
+func (s *service) Logout(ctx context.Context, value string) error {
+	if value == "" {
+		return models.ErrInvalidCookie{}
+	}
 
-	return token.SignedString([]byte(viper.GetString("JWT_SIGNING_KEY")))
+	// access tokens are short lived, an expired one still identifies the session to log out.
+	claims, err := parseJWT(&jwt.Parser{SkipClaimsValidation: true}, s.keys, value)
+	if err != nil {
+		return err
+	}
+
+	// the json web token is denied first, the user is logged out even if google cannot be reached.
+	if err := s.denylist.Add(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error denying the jwt of the user=%s", claims.ID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return err
+	}
+
+	if claims.SessionID != "" {
+		if err := s.repo.RevokeSessionFamily(ctx, claims.SessionID); err != nil {
+			s.logger.Log(
+				"message", fmt.Sprintf("error revoking session=%s of the user=%s", claims.SessionID, claims.ID),
+				"error", err.Error(),
+				"severity", "ERROR",
+			)
+			return err
+		}
+	}
+
+	// the gmail service is evicted last, a recreation racing with the logout finds the token already deactivated.
+	defer s.mailxService.Evict(claims.ID)
+
+	token, err := s.repo.GetTokenByUserId(ctx, claims.ID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+
+	if err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error by getting token for the user=%s", claims.ID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return err
+	}
+
+	if err := s.revokeToken(ctx, token); err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("could not revoke the google token of the user=%s", claims.ID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+	}
+
+	if err := s.repo.DeactivateToken(ctx, claims.ID); err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error deactivating token for the user=%s", claims.ID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return err
+	}
+
+	return nil
+}
+
+// revokeToken revokes the google grant, revoking the refresh token also revokes the access tokens issued with it.
+func (s *service) revokeToken(ctx context.Context, token *models.Token) error {
+	value := token.RefreshToken
+	if value == "" {
+		value = token.AccessToken
+	}
+
+	form := url.Values{"token": {value}}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.revokeUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(response.Body)
+		return fmt.Errorf("revocation failed with status %d: %s", response.StatusCode, body)
+	}
+	return nil
 }
 
 func (s *service) createUser(ctx context.Context, token *oauth2.Token) (*models.User, error) {
@@ -189,7 +405,8 @@ func (s *service) saveAccessToken(ctx context.Context, ID string, token *oauth2.
 		return err
 	}
 
-	if err := s.repo.UpdateAccessToken(ctx, ID, token); err != nil {
+	// signing in again is the only way to reactivate a token deactivated by a logout or a revoked grant.
+	if err := s.repo.ReactivateAccessToken(ctx, ID, token); err != nil {
 		return err
 	}
 
@@ -3,17 +3,25 @@ package auth
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/base64"
 	"errors"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/go-kit/log"
-	"github.com/google/uuid"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/orlandorode97/mailx-google-service"
 	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/keyring"
 	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"golang.org/x/oauth2"
@@ -28,8 +36,8 @@ func (m MockMailxService) GetGmailService(userID string) google.Service {
 	return args.Get(0).(google.Service)
 }
 
-func (m MockMailxService) CreateGmailService(token *oauth2.Token) (google.Service, error) {
-	args := m.Called(token)
+func (m MockMailxService) CreateGmailService(ctx context.Context, userID string, token *oauth2.Token) (google.Service, error) {
+	args := m.Called(ctx, userID, token)
 	return args.Get(0).(google.Service), args.Error(1)
 }
 
@@ -38,6 +46,15 @@ func (m MockMailxService) AddGmailServiceByID(userID string, gmailSvc google.Ser
 	return args.Get(0).(google.Service)
 }
 
+func (m MockMailxService) Evict(userID string) {
+	m.Called(userID)
+}
+
+func (m MockMailxService) CacheStats() mailx.CacheStats {
+	args := m.Called()
+	return args.Get(0).(mailx.CacheStats)
+}
+
 func (m MockMailxService) RecreateGmailService(ctx context.Context, userID string) (google.Service, error) {
 	args := m.Called(ctx, userID)
 	return args.Get(0).(google.Service), args.Error(1)
@@ -104,50 +121,165 @@ func (db MockDB) UpdateAccessToken(ctx context.Context, ID string, token *oauth2
 	return args.Error(0)
 }
 
+func (db MockDB) ReactivateAccessToken(ctx context.Context, ID string, token *oauth2.Token) error {
+	args := db.Called(ctx, ID, token)
+	return args.Error(0)
+}
+
+func (db MockDB) DeactivateToken(ctx context.Context, ID string) error {
+	args := db.Called(ctx, ID)
+	return args.Error(0)
+}
+
+func (db MockDB) CreateSession(ctx context.Context, session *models.Session) error {
+	args := db.Called(ctx, session)
+	return args.Error(0)
+}
+
+func (db MockDB) GetSessionByTokenHash(ctx context.Context, hash string) (*models.Session, error) {
+	args := db.Called(ctx, hash)
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+func (db MockDB) RotateSession(ctx context.Context, ID string, next *models.Session) error {
+	args := db.Called(ctx, ID, next)
+	return args.Error(0)
+}
+
+func (db MockDB) RevokeSessionFamily(ctx context.Context, familyID string) error {
+	args := db.Called(ctx, familyID)
+	return args.Error(0)
+}
+
 func TestGetOauthUrl(t *testing.T) {
 	testscases := []struct {
 		name        string
-		state       string
 		expectedUrl string
 		expectedErr error
 		assertErr   func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
-		assertUrl   func(t assert.TestingT, expected interface{}, actual interface{}, msgAndArgs ...interface{}) bool
 	}{
 		{
-			name:        "success - url is returned by TestGetOauthUrl",
-			state:       uuid.NewString(),
+			name:        "success - url is returned and its state is stored",
 			expectedUrl: "http://localhost:3000",
-			expectedErr: nil,
 			assertErr:   assert.Nil,
-			assertUrl:   assert.Equal,
 		},
 		{
-			name:        "failure - error returned by generating url by TestGetOauthUrl",
-			state:       uuid.NewString(),
+			name:        "failure - error returned by generating url",
 			expectedUrl: "",
 			expectedErr: models.ErrAuthUrl{},
 			assertErr:   assert.NotNil,
-			assertUrl:   assert.Equal,
 		},
 	}
 
 	for _, test := range testscases {
 		t.Run(test.name, func(t *testing.T) {
+			var opts []oauth2.AuthCodeOption
 			config := MockOAuthConfig{}
-			config.On("AuthCodeURL", test.state, []oauth2.AuthCodeOption{oauth2.AccessTypeOffline}).Return(test.expectedUrl)
-			svc := &AuthServiceMock{
+			config.On("AuthCodeURL", mock.Anything, mock.Anything).Return(test.expectedUrl).Run(func(args mock.Arguments) {
+				opts = args.Get(1).([]oauth2.AuthCodeOption)
+			})
+			svc := &service{
+				logger: log.NewLogfmtLogger(os.Stdin),
 				config: config,
+				states: NewMemoryStateStore(),
 			}
-			svc.GetOauthUrl = func(c context.Context) (string, error) {
-				url := svc.config.AuthCodeURL(test.state, oauth2.AccessTypeOffline)
-				if url == "" {
-					return "", models.ErrAuthUrl{}
-				}
-				return url, nil
+			authUrl, state, err := svc.GetOauthUrl(context.Background(), false)
+			test.assertErr(t, err)
+			assert.Equal(t, test.expectedErr, err)
+			assert.Equal(t, test.expectedUrl, authUrl)
+			if err != nil {
+				return
+			}
+
+			assert.NotEmpty(t, state)
+			verifier, err := svc.ValidateOauthState(context.Background(), state, state, false)
+			assert.Nil(t, err)
+			assert.Len(t, verifier, 43)
+
+			// the options are rendered by a real config to read the parameters sent to google.
+			googleUrl, _ := url.Parse((&oauth2.Config{Endpoint: oauth2.Endpoint{AuthURL: "https://accounts.google.com/o/oauth2/auth"}}).AuthCodeURL(state, opts...))
+			sum := sha256.Sum256([]byte(verifier))
+			assert.Equal(t, "offline", googleUrl.Query().Get("access_type"))
+			assert.Equal(t, "S256", googleUrl.Query().Get("code_challenge_method"))
+			assert.Equal(t, base64.RawURLEncoding.EncodeToString(sum[:]), googleUrl.Query().Get("code_challenge"))
+		})
+	}
+}
+
+func TestValidateOauthState(t *testing.T) {
+	testcases := []struct {
+		name         string
+		state        string
+		browserState string
+		stored       bool
+		storedNative bool
+		native       bool
+		expectedErr  error
+	}{
+		{
+			name:         "success - state matches the browser state and is stored.",
+			state:        "state-1",
+			browserState: "state-1",
+			stored:       true,
+		},
+		{
+			name:         "failure - missing state.",
+			browserState: "state-1",
+			expectedErr:  models.ErrInvalidState{},
+		},
+		{
+			name:         "failure - state does not match the browser state.",
+			state:        "state-1",
+			browserState: "state-2",
+			stored:       true,
+			expectedErr:  models.ErrInvalidState{},
+		},
+		{
+			name:         "failure - state was not issued by the service.",
+			state:        "state-1",
+			browserState: "state-1",
+			expectedErr:  models.ErrInvalidState{},
+		},
+		{
+			name:         "success - native state is stored without a browser state.",
+			state:        "state-1",
+			stored:       true,
+			storedNative: true,
+			native:       true,
+		},
+		{
+			name:        "failure - browser state is used by a native callback.",
+			state:       "state-1",
+			stored:      true,
+			native:      true,
+			expectedErr: models.ErrInvalidState{},
+		},
+		{
+			name:         "failure - native state is used by a browser callback.",
+			state:        "state-1",
+			browserState: "state-1",
+			stored:       true,
+			storedNative: true,
+			expectedErr:  models.ErrInvalidState{},
+		},
+		{
+			name:        "failure - native callback without state.",
+			native:      true,
+			expectedErr: models.ErrInvalidState{},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			svc := &service{states: NewMemoryStateStore()}
+			if test.stored {
+				_ = svc.states.Save(context.Background(), test.state, LoginSession{Verifier: "verifier", Native: test.storedNative}, time.Now().Add(stateTTL))
+			}
+			verifier, err := svc.ValidateOauthState(context.Background(), test.state, test.browserState, test.native)
+			assert.Equal(t, test.expectedErr, err)
+			if err == nil {
+				assert.Equal(t, "verifier", verifier)
 			}
-			url, err := svc.GetOauthUrl(context.Background())
-			test.assertErr(t, err, test.expectedErr)
-			test.assertUrl(t, test.expectedUrl, url)
 		})
 	}
 }
@@ -188,13 +320,13 @@ func TestGenerateOauthToken(t *testing.T) {
 	for _, test := range testcases {
 		t.Run(test.name, func(t *testing.T) {
 			config := MockOAuthConfig{}
-			config.On("Exchange", test.ctx, test.code, []oauth2.AuthCodeOption(nil)).Return(test.token, test.tokenErr)
+			config.On("Exchange", test.ctx, test.code, []oauth2.AuthCodeOption{verifierOption("verifier")}).Return(test.token, test.tokenErr)
 			svc := &service{
 				config: config,
 				logger: log.NewLogfmtLogger(os.Stdin),
 			}
 
-			token, err := svc.GenerateOauthToken(test.ctx, test.code)
+			token, err := svc.GenerateOauthToken(test.ctx, test.code, "verifier")
 			test.assertErr(t, err)
 			test.assertToken(t, token)
 
@@ -250,11 +382,14 @@ func TestConfigGmailServiceUser(t *testing.T) {
 			token: &oauth2.Token{
 				AccessToken: "random access token",
 			},
-			gmailSvc:     &google.GmailService{},
-			gmailSvcErr:  errors.New("error gmail service cannot be configured."),
-			expectedUser: &models.User{},
-			assertErr:    assert.NotNil,
-			assertEqual:  assert.NotEqual,
+			gmailSvc:    &google.GmailService{},
+			gmailSvcErr: errors.New("error gmail service cannot be configured."),
+			expectedUser: &models.User{
+				ID:   "1",
+				Name: "Orlando",
+			},
+			assertErr:   assert.NotNil,
+			assertEqual: assert.NotEqual,
 		},
 		{
 			name: "failure - cannot create gmail user",
@@ -293,7 +428,7 @@ func TestConfigGmailServiceUser(t *testing.T) {
 	for _, test := range testscases {
 		t.Run(test.name, func(t *testing.T) {
 			config := MockOAuthConfig{}
-			config.On("Exchange", test.ctx, test.code, []oauth2.AuthCodeOption(nil)).Return(test.token, test.tokenErr)
+			config.On("Exchange", test.ctx, test.code, []oauth2.AuthCodeOption{verifierOption("verifier")}).Return(test.token, test.tokenErr)
 
 			db := MockDB{}
 			db.On("GetUserByID", test.ctx, test.expectedUser.ID).Return(&models.User{}, test.expectedUserErr)
@@ -301,10 +436,10 @@ func TestConfigGmailServiceUser(t *testing.T) {
 
 			db.On("GetTokenByUserId", test.ctx, test.expectedUser.ID).Return(&models.Token{}, test.saveTokenErr)
 			db.On("SaveAccessToken", test.ctx, test.expectedUser.ID, test.token).Return(test.saveTokenErr)
-			db.On("UpdateAccessToken", test.ctx, test.expectedUser.ID, test.token).Return(test.saveTokenErr)
+			db.On("ReactivateAccessToken", test.ctx, test.expectedUser.ID, test.token).Return(test.saveTokenErr)
 
 			mockMailxService := MockMailxService{}
-			mockMailxService.On("CreateGmailService", test.token).Return(test.gmailSvc, test.gmailSvcErr)
+			mockMailxService.On("CreateGmailService", mock.Anything, mock.Anything, test.token).Return(test.gmailSvc, test.gmailSvcErr)
 			mockMailxService.On("AddGmailServiceByID", test.expectedUser.ID, test.gmailSvc).Return(test.gmailSvc)
 
 			client := NewTestClient(func(req *http.Request) *http.Response {
@@ -321,7 +456,7 @@ func TestConfigGmailServiceUser(t *testing.T) {
 				client:       client,
 				mailxService: mockMailxService,
 			}
-			user, err := svc.ConfigGmailServiceUser(test.ctx, test.code)
+			user, err := svc.ConfigGmailServiceUser(test.ctx, test.code, "verifier")
 			test.assertErr(t, err)
 			test.assertEqual(t, test.expectedUser, user)
 		})
@@ -537,7 +672,7 @@ func TestSaveAccessToken(t *testing.T) {
 			db := MockDB{}
 			db.On("GetTokenByUserId", test.ctx, test.user.ID).Return(test.mailxToken, test.getTokenByUserIdErr)
 			db.On("SaveAccessToken", test.ctx, test.user.ID, test.token).Return(test.saveAccessTokenErr)
-			db.On("UpdateAccessToken", test.ctx, test.user.ID, test.token).Return(test.updateAccessTokenErr)
+			db.On("ReactivateAccessToken", test.ctx, test.user.ID, test.token).Return(test.updateAccessTokenErr)
 			logger := log.NewLogfmtLogger(os.Stdin)
 			svc := service{
 				repo:   db,
@@ -548,3 +683,334 @@ func TestSaveAccessToken(t *testing.T) {
 		})
 	}
 }
+
+func newTestKeyRing(t *testing.T, secret string) *keyring.KeyRing {
+	keys, err := keyring.New("test", keyring.NewHMACKey("test", []byte(secret)))
+	if err != nil {
+		t.Fatalf("cannot create key ring: %v", err)
+	}
+	return keys
+}
+
+func TestParseJWT(t *testing.T) {
+	keys := newTestKeyRing(t, "signing-key")
+	svc := &service{keys: keys}
+
+	valid, _, err := svc.createJWT("1", "session-1", time.Now())
+	assert.Nil(t, err)
+
+	expired, _ := keys.Sign(MailxClaims{
+		ID:             "1",
+		StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(-time.Hour).Unix()},
+	})
+
+	otherKey, _ := newTestKeyRing(t, "another-key").Sign(MailxClaims{
+		ID:             "1",
+		StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Hour).Unix()},
+	})
+
+	withoutKid, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, MailxClaims{
+		ID:             "1",
+		StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Hour).Unix()},
+	}).SignedString([]byte("signing-key"))
+
+	testcases := []struct {
+		name        string
+		value       string
+		expectedErr error
+	}{
+		{
+			name:  "success - claims are returned.",
+			value: valid,
+		},
+		{
+			name:        "failure - token has expired.",
+			value:       expired,
+			expectedErr: models.ErrExpiredToken{},
+		},
+		{
+			name:        "failure - token signed with another key.",
+			value:       otherKey,
+			expectedErr: models.ErrInvalidSignature{},
+		},
+		{
+			name:        "failure - token without kid header.",
+			value:       withoutKid,
+			expectedErr: models.ErrInvalidSignature{},
+		},
+		{
+			name:        "failure - token is malformed.",
+			value:       "not-a-jwt",
+			expectedErr: models.ErrMalformedToken{},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			claims, err := ParseJWT(keys, test.value)
+			assert.Equal(t, test.expectedErr, err)
+			if err == nil {
+				assert.Equal(t, "1", claims.ID)
+				assert.Equal(t, "session-1", claims.SessionID)
+				assert.NotEmpty(t, claims.Id)
+			}
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	testcases := []struct {
+		name          string
+		jwt           bool
+		expired       bool
+		token         *models.Token
+		tokenErr      error
+		revokeStatus  int
+		deactivateErr error
+		expectedErr   error
+		revoked       string
+	}{
+		{
+			name:         "success - google grant is revoked and the token is deactivated.",
+			jwt:          true,
+			token:        &models.Token{AccessToken: "access", RefreshToken: "refresh"},
+			revokeStatus: http.StatusOK,
+			revoked:      "refresh",
+		},
+		{
+			name:         "success - user is logged out even if google rejects the revocation.",
+			jwt:          true,
+			token:        &models.Token{AccessToken: "access"},
+			revokeStatus: http.StatusBadRequest,
+			revoked:      "access",
+		},
+		{
+			name:         "success - expired jwt still logs out its session.",
+			jwt:          true,
+			expired:      true,
+			token:        &models.Token{RefreshToken: "refresh"},
+			revokeStatus: http.StatusOK,
+			revoked:      "refresh",
+		},
+		{
+			name:     "success - user without a stored token.",
+			jwt:      true,
+			token:    &models.Token{},
+			tokenErr: sql.ErrNoRows,
+		},
+		{
+			name:          "failure - token cannot be deactivated.",
+			jwt:           true,
+			token:         &models.Token{RefreshToken: "refresh"},
+			revokeStatus:  http.StatusOK,
+			deactivateErr: errors.New("connection refused"),
+			expectedErr:   errors.New("connection refused"),
+			revoked:       "refresh",
+		},
+		{
+			name:        "failure - missing jwt.",
+			expectedErr: models.ErrInvalidCookie{},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			var revoked string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				revoked = r.FormValue("token")
+				w.WriteHeader(test.revokeStatus)
+			}))
+			defer server.Close()
+
+			var calls []string
+			db := MockDB{}
+			db.On("GetTokenByUserId", mock.Anything, "1").Return(test.token, test.tokenErr)
+			db.On("DeactivateToken", mock.Anything, "1").Return(test.deactivateErr).Run(func(args mock.Arguments) {
+				calls = append(calls, "DeactivateToken")
+			})
+			db.On("RevokeSessionFamily", mock.Anything, "session-1").Return(nil)
+
+			mockMailxService := MockMailxService{}
+			mockMailxService.On("Evict", "1").Run(func(args mock.Arguments) {
+				calls = append(calls, "Evict")
+			})
+
+			svc := &service{
+				logger:       log.NewLogfmtLogger(os.Stdin),
+				repo:         db,
+				client:       server.Client(),
+				mailxService: mockMailxService,
+				denylist:     NewMemoryDenylist(),
+				keys:         newTestKeyRing(t, "signing-key"),
+				revokeUrl:    server.URL,
+			}
+
+			var value string
+			if test.jwt {
+				expiresAt := time.Now().Add(accessTokenTTL)
+				if test.expired {
+					expiresAt = time.Now().Add(-time.Hour)
+				}
+				value, _ = svc.keys.Sign(MailxClaims{
+					ID:        "1",
+					SessionID: "session-1",
+					StandardClaims: jwt.StandardClaims{
+						Id:        "jwt-1",
+						ExpiresAt: expiresAt.Unix(),
+					},
+				})
+			}
+
+			err := svc.Logout(context.Background(), value)
+			assert.Equal(t, test.expectedErr, err)
+			assert.Equal(t, test.revoked, revoked)
+
+			if test.jwt {
+				denied, _ := svc.denylist.Contains(context.Background(), "jwt-1")
+				assert.True(t, denied)
+				// the gmail service is evicted after the token is deactivated.
+				assert.Equal(t, "Evict", calls[len(calls)-1])
+			}
+		})
+	}
+}
+
+func TestCreateSession(t *testing.T) {
+	testcases := []struct {
+		name        string
+		sessionErr  error
+		expectedErr error
+	}{
+		{
+			name: "success - session is created.",
+		},
+		{
+			name:        "failure - session cannot be stored.",
+			sessionErr:  errors.New("connection refused"),
+			expectedErr: errors.New("connection refused"),
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			var stored *models.Session
+			db := MockDB{}
+			db.On("CreateSession", mock.Anything, mock.Anything).Return(test.sessionErr).Run(func(args mock.Arguments) {
+				stored = args.Get(1).(*models.Session)
+			})
+
+			svc := &service{
+				logger: log.NewLogfmtLogger(os.Stdin),
+				repo:   db,
+				keys:   newTestKeyRing(t, "signing-key"),
+			}
+			tokens, err := svc.CreateSession(context.Background(), &models.User{ID: "1"})
+			assert.Equal(t, test.expectedErr, err)
+			if err != nil {
+				assert.Nil(t, tokens)
+				return
+			}
+
+			assert.Equal(t, "1", stored.UserID)
+			assert.Equal(t, hashToken(tokens.RefreshToken), stored.TokenHash)
+			assert.NotEqual(t, tokens.RefreshToken, stored.TokenHash)
+
+			claims, err := ParseJWT(svc.keys, tokens.AccessToken)
+			assert.Nil(t, err)
+			assert.Equal(t, stored.FamilyID, claims.SessionID)
+			assert.WithinDuration(t, time.Now().Add(accessTokenTTL), time.Unix(claims.ExpiresAt, 0), time.Minute)
+		})
+	}
+}
+
+func TestRefreshSession(t *testing.T) {
+	viper.Set("JWT_SIGNING_KEY", "signing-key")
+	rotatedAt := time.Now().Add(-time.Minute)
+
+	testcases := []struct {
+		name         string
+		refreshToken string
+		session      *models.Session
+		sessionErr   error
+		rotateErr    error
+		revoked      bool
+		expectedErr  error
+	}{
+		{
+			name:         "success - refresh token is rotated.",
+			refreshToken: "refresh-token",
+			session:      &models.Session{ID: "session-1", FamilyID: "family-1", UserID: "1", ExpiresAt: time.Now().Add(time.Hour)},
+		},
+		{
+			name:         "failure - missing refresh token.",
+			session:      &models.Session{},
+			expectedErr:  models.ErrInvalidSession{},
+			refreshToken: "",
+		},
+		{
+			name:         "failure - unknown refresh token.",
+			refreshToken: "refresh-token",
+			session:      &models.Session{},
+			sessionErr:   sql.ErrNoRows,
+			expectedErr:  models.ErrInvalidSession{},
+		},
+		{
+			name:         "failure - expired session.",
+			refreshToken: "refresh-token",
+			session:      &models.Session{ID: "session-1", FamilyID: "family-1", UserID: "1", ExpiresAt: time.Now().Add(-time.Hour)},
+			expectedErr:  models.ErrInvalidSession{},
+		},
+		{
+			name:         "failure - reused refresh token revokes the family.",
+			refreshToken: "refresh-token",
+			session:      &models.Session{ID: "session-1", FamilyID: "family-1", UserID: "1", ExpiresAt: time.Now().Add(time.Hour), RotatedAt: &rotatedAt},
+			revoked:      true,
+			expectedErr:  models.ErrInvalidSession{},
+		},
+		{
+			name:         "failure - concurrent rotation revokes the family.",
+			refreshToken: "refresh-token",
+			session:      &models.Session{ID: "session-1", FamilyID: "family-1", UserID: "1", ExpiresAt: time.Now().Add(time.Hour)},
+			rotateErr:    models.ErrInvalidSession{},
+			revoked:      true,
+			expectedErr:  models.ErrInvalidSession{},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			var next *models.Session
+			revoked := false
+			db := MockDB{}
+			db.On("GetSessionByTokenHash", mock.Anything, hashToken(test.refreshToken)).Return(test.session, test.sessionErr)
+			db.On("RotateSession", mock.Anything, test.session.ID, mock.Anything).Return(test.rotateErr).Run(func(args mock.Arguments) {
+				next = args.Get(2).(*models.Session)
+			})
+			db.On("RevokeSessionFamily", mock.Anything, test.session.FamilyID).Return(nil).Run(func(args mock.Arguments) {
+				revoked = true
+			})
+
+			svc := &service{
+				logger: log.NewLogfmtLogger(os.Stdin),
+				repo:   db,
+				keys:   newTestKeyRing(t, "signing-key"),
+			}
+			tokens, err := svc.RefreshSession(context.Background(), test.refreshToken)
+			assert.Equal(t, test.expectedErr, err)
+			assert.Equal(t, test.revoked, revoked)
+			if err != nil {
+				assert.Nil(t, tokens)
+				return
+			}
+
+			assert.Equal(t, test.session.FamilyID, next.FamilyID)
+			assert.NotEqual(t, test.session.ID, next.ID)
+			assert.Equal(t, hashToken(tokens.RefreshToken), next.TokenHash)
+			assert.NotEqual(t, test.refreshToken, tokens.RefreshToken)
+
+			claims, err := ParseJWT(svc.keys, tokens.AccessToken)
+			assert.Nil(t, err)
+			assert.Equal(t, "family-1", claims.SessionID)
+		})
+	}
+}
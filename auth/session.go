@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+)
+
+const (
+	// accessTokenTTL keeps the json web tokens short lived, clients refresh them with the refresh token.
+	accessTokenTTL = 15 * time.Minute
+	// refreshTokenTTL bounds the inactivity of a session, every refresh extends it.
+	refreshTokenTTL = 30 * 24 * time.Hour
+	// refreshCookieName holds the refresh token, it is only sent to the auth endpoints.
+	refreshCookieName = "mailx_google_refresh"
+)
+
+// SessionTokens is the pair of tokens returned when a session is created or refreshed.
+type SessionTokens struct {
+	AccessToken      string
+	AccessIssuedAt   time.Time
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+func (s *service) CreateSession(ctx context.Context, user *models.User) (*SessionTokens, error) {
+	session, refreshToken, err := newSession(user.ID, uuid.NewString())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateSession(ctx, session); err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error creating session for the user=%s", user.ID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return nil, err
+	}
+
+	return s.sessionTokens(session, refreshToken)
+}
+
+func (s *service) RefreshSession(ctx context.Context, refreshToken string) (*SessionTokens, error) {
+	if refreshToken == "" {
+		return nil, models.ErrInvalidSession{}
+	}
+
+	session, err := s.repo.GetSessionByTokenHash(ctx, hashToken(refreshToken))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrInvalidSession{}
+	}
+
+	if err != nil {
+		s.logger.Log(
+			"message", "error getting session by refresh token",
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return nil, err
+	}
+
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, models.ErrInvalidSession{}
+	}
+
+	if session.RotatedAt != nil {
+		return nil, s.revokeReusedSession(ctx, session)
+	}
+
+	next, nextToken, err := newSession(session.UserID, session.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.RotateSession(ctx, session.ID, next)
+	if _, ok := err.(models.ErrInvalidSession); ok {
+		// another request rotated the session in the meantime with the same refresh token.
+		return nil, s.revokeReusedSession(ctx, session)
+	}
+
+	if err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error rotating session=%s for the user=%s", session.ID, session.UserID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return nil, err
+	}
+
+	return s.sessionTokens(next, nextToken)
+}
+
+// revokeReusedSession revokes the family of a refresh token that was already rotated, either the legitimate
+// client or an attacker holds a stolen copy of it and there is no way to tell them apart.
+func (s *service) revokeReusedSession(ctx context.Context, session *models.Session) error {
+	s.logger.Log(
+		"message", fmt.Sprintf("refresh token reused for session=%s, revoking family=%s of the user=%s", session.ID, session.FamilyID, session.UserID),
+		"severity", "WARNING",
+	)
+
+	if err := s.repo.RevokeSessionFamily(ctx, session.FamilyID); err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error revoking family=%s of the user=%s", session.FamilyID, session.UserID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return err
+	}
+
+	return models.ErrInvalidSession{}
+}
+
+// newSession returns a session of the family along with its refresh token, only the hash of the token is stored.
+func newSession(userID string, familyID string) (*models.Session, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	refreshToken := base64.RawURLEncoding.EncodeToString(b)
+
+	return &models.Session{
+		ID:        uuid.NewString(),
+		FamilyID:  familyID,
+		UserID:    userID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	}, refreshToken, nil
+}
+
+func (s *service) sessionTokens(session *models.Session, refreshToken string) (*SessionTokens, error) {
+	issuedAt := time.Now()
+	accessToken, expiresAt, err := s.createJWT(session.UserID, session.FamilyID, issuedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SessionTokens{
+		AccessToken:      accessToken,
+		AccessIssuedAt:   issuedAt,
+		AccessExpiresAt:  expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+)
+
+const (
+	// stateTTL bounds the time between the login request and the google callback.
+	stateTTL = 10 * time.Minute
+	// stateCookieName binds the oauth state to the browser that requested the login.
+	stateCookieName = "mailx_oauth_state"
+)
+
+// LoginSession is what the login endpoint generates along with an oauth state.
+type LoginSession struct {
+	// Verifier is the PKCE code verifier of the authorization code.
+	Verifier string
+	// Native marks the logins of native clients, their state is not bound to a browser cookie.
+	Native bool
+}
+
+// StateStore keeps the login sessions issued by the login endpoint until the callback consumes them.
+type StateStore interface {
+	Save(ctx context.Context, state string, session LoginSession, expiration time.Time) error
+	// Consume removes the state and returns its login session. It fails when the state is unknown or expired,
+	// so every state is accepted only once.
+	Consume(ctx context.Context, state string) (LoginSession, error)
+}
+
+type storedSession struct {
+	LoginSession
+	expiration time.Time
+}
+
+type memoryStateStore struct {
+	mu     sync.Mutex
+	states map[string]storedSession
+	now    func() time.Time
+}
+
+// NewMemoryStateStore returns a StateStore that keeps the login sessions in memory.
+func NewMemoryStateStore() StateStore {
+	return &memoryStateStore{
+		states: make(map[string]storedSession),
+		now:    time.Now,
+	}
+}
+
+func (m *memoryStateStore) Save(_ context.Context, state string, session LoginSession, expiration time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// abandoned logins never reach the callback, drop their states while saving new ones.
+	now := m.now()
+	for s, stored := range m.states {
+		if now.After(stored.expiration) {
+			delete(m.states, s)
+		}
+	}
+
+	m.states[state] = storedSession{LoginSession: session, expiration: expiration}
+	return nil
+}
+
+func (m *memoryStateStore) Consume(_ context.Context, state string) (LoginSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.states[state]
+	if !ok {
+		return LoginSession{}, models.ErrInvalidState{}
+	}
+	delete(m.states, state)
+
+	if m.now().After(stored.expiration) {
+		return LoginSession{}, models.ErrInvalidState{}
+	}
+	return stored.LoginSession, nil
+}
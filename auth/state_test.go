@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStateStore(t *testing.T) {
+	t.Run("success - state is consumed only once.", func(t *testing.T) {
+		store := NewMemoryStateStore()
+		assert.Nil(t, store.Save(context.Background(), "state", LoginSession{Verifier: "verifier"}, time.Now().Add(stateTTL)))
+
+		session, err := store.Consume(context.Background(), "state")
+		assert.Nil(t, err)
+		assert.Equal(t, LoginSession{Verifier: "verifier"}, session)
+
+		_, err = store.Consume(context.Background(), "state")
+		assert.Equal(t, models.ErrInvalidState{}, err)
+	})
+
+	t.Run("failure - expired state is rejected.", func(t *testing.T) {
+		now := time.Now()
+		store := &memoryStateStore{
+			states: make(map[string]storedSession),
+			now:    func() time.Time { return now },
+		}
+		assert.Nil(t, store.Save(context.Background(), "state", LoginSession{Verifier: "verifier"}, now.Add(stateTTL)))
+
+		now = now.Add(stateTTL + time.Second)
+		_, err := store.Consume(context.Background(), "state")
+		assert.Equal(t, models.ErrInvalidState{}, err)
+	})
+
+	t.Run("success - expired states are dropped when saving.", func(t *testing.T) {
+		now := time.Now()
+		store := &memoryStateStore{
+			states: make(map[string]storedSession),
+			now:    func() time.Time { return now },
+		}
+		assert.Nil(t, store.Save(context.Background(), "abandoned", LoginSession{Verifier: "verifier"}, now.Add(stateTTL)))
+
+		now = now.Add(stateTTL + time.Second)
+		assert.Nil(t, store.Save(context.Background(), "state", LoginSession{Verifier: "verifier"}, now.Add(stateTTL)))
+		assert.Len(t, store.states, 1)
+	})
+}
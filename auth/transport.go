@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/go-kit/log"
@@ -20,7 +22,8 @@ func MakeHandler(authSvc Service, logger log.Logger) http.Handler {
 		kithttp.ServerErrorEncoder(models.ErrorEncoder),
 	}
 
-	r.Methods(http.MethodGet).
+	// logout changes state, a POST keeps cross site links and images from logging the user out.
+	r.Methods(http.MethodPost).
 		Path("/auth/logout/").
 		Handler(kithttp.NewServer(
 			e.LogoutEndpoint,
@@ -36,6 +39,22 @@ func MakeHandler(authSvc Service, logger log.Logger) http.Handler {
 			encodeLoginResponse,
 			options...,
 		))
+	r.Methods(http.MethodGet).
+		Path("/.well-known/jwks.json").
+		Handler(kithttp.NewServer(
+			e.GetJWKSEndpoint,
+			decodeJWKSRequest,
+			encodeJWKSResponse,
+			options...,
+		))
+	r.Methods(http.MethodPost).
+		Path("/auth/refresh").
+		Handler(kithttp.NewServer(
+			e.RefreshEndpoint,
+			decodeRefreshRequest,
+			encodeRefreshResponse,
+			options...,
+		))
 	r.Methods(http.MethodGet).
 		Path("/auth/callback/").
 		Handler(kithttp.NewServer(
@@ -47,40 +66,109 @@ func MakeHandler(authSvc Service, logger log.Logger) http.Handler {
 	return r
 }
 
+/*
+TokenFromRequest returns the json web token of the request. The token is read from:
+ 1. the `Authorization: Bearer <jwt>` header, used by native clients and scripts.
+ 2. the `mailx_google_auth` cookie, used by the browser.
+
+The header takes precedence, a request with an Authorization header is never authenticated by its cookie.
+*/
+func TokenFromRequest(r *http.Request) (string, error) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		parts := strings.SplitN(header, " ", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") || strings.TrimSpace(parts[1]) == "" {
+			return "", models.ErrInvalidToken{}
+		}
+		return strings.TrimSpace(parts[1]), nil
+	}
+
+	cookie, err := r.Cookie("mailx_google_auth")
+	if err != nil || cookie.Value == "" {
+		return "", models.ErrInvalidCookie{}
+	}
+	return cookie.Value, nil
+}
+
+// wantsJSON reports whether the client asked for a json response instead of cookies and redirects.
+func wantsJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
 func decodeLogoutRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	return logoutRequest{}, nil
+	token, _ := TokenFromRequest(r)
+	return logoutRequest{JWT: token}, nil
 }
 
-func encodeLogoutResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
-	cookie := &http.Cookie{
-		Name:     "mailx_google_auth",
-		Value:    "",
-		Path:     "/",
-		HttpOnly: true,
-		MaxAge:   -1,
+func encodeLogoutResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	// the cookies are cleared even when the logout fails, e.g. with a malformed token.
+	clearSessionCookies(w)
+	if e, ok := response.(errorer); ok && e.error() != nil {
+		models.ErrorEncoder(ctx, e.error(), w)
 	}
-
-	w.Header().Add("Set-Cookie", cookie.String())
 	return nil
 }
 
 func decodeLoginRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	return loginRequest{}, nil
+	return loginRequest{JSON: wantsJSON(r)}, nil
 }
 
 func encodeLoginResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
-	// check for possible errors coming form the response
+	resp, ok := response.(loginResponse)
+	if ok && resp.JSON {
+		// native clients keep the state themselves and send it back to the callback.
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		return json.NewEncoder(w).Encode(resp)
+	}
+
+	if ok && resp.State != "" {
+		cookie := &http.Cookie{
+			Name:     stateCookieName,
+			Value:    resp.State,
+			Path:     "/auth/",
+			HttpOnly: true,
+			MaxAge:   int(stateTTL.Seconds()),
+			// google redirects back with a top level navigation, lax cookies are sent on it.
+			SameSite: http.SameSiteLaxMode,
+		}
+		w.Header().Add("Set-Cookie", cookie.String())
+		// the browser state lives only in the cookie.
+		resp.State = ""
+		return json.NewEncoder(w).Encode(resp)
+	}
 	return json.NewEncoder(w).Encode(response)
 }
 
 func decodeCallbackRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	return callbackRequest{
+	request := callbackRequest{
 		State: r.FormValue("state"),
 		Code:  r.FormValue("code"),
-	}, nil
+		JSON:  wantsJSON(r),
+	}
+	if cookie, err := r.Cookie(stateCookieName); err == nil {
+		request.BrowserState = cookie.Value
+	}
+	return request, nil
 }
 
-func encodeCallbackResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+func encodeCallbackResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	stateCookie := &http.Cookie{
+		Name:     stateCookieName,
+		Value:    "",
+		Path:     "/auth/",
+		HttpOnly: true,
+		MaxAge:   -1,
+	}
+	w.Header().Add("Set-Cookie", stateCookie.String())
+
+	if resp, ok := response.(callbackResponse); ok && resp.JSON {
+		if resp.Err != nil {
+			models.ErrorEncoder(ctx, resp.Err, w)
+			return nil
+		}
+		return encodeTokens(w, resp.Tokens)
+	}
+
 	var redirectUrl string
 	if e, ok := response.(errorer); ok && e.error() != nil {
 		redirectUrl = fmt.Sprintf("%s/error?error_message=%s", viper.GetString("MAILX_APP_URL"), e.error().Error())
@@ -89,18 +177,125 @@ func encodeCallbackResponse(_ context.Context, w http.ResponseWriter, response i
 	}
 
 	resp, _ := response.(callbackResponse)
-	cookie := &http.Cookie{
+	setSessionCookies(w, resp.Tokens)
+
+	redirectUrl = fmt.Sprintf("%s/success?mailx_google_success=true", viper.GetString("MAILX_APP_URL"))
+	http.Redirect(w, &http.Request{}, redirectUrl, http.StatusPermanentRedirect)
+	return nil
+}
+
+func decodeJWKSRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return jwksRequest{}, nil
+}
+
+func encodeJWKSResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	// the verifiers refetch the keys when they find an unknown kid, a short cache is enough.
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	return json.NewEncoder(w).Encode(response)
+}
+
+// decodeRefreshRequest reads the refresh token from the cookie, or from the `refresh_token` form value for native clients.
+func decodeRefreshRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	request := refreshRequest{
+		RefreshToken: r.PostFormValue("refresh_token"),
+		JSON:         wantsJSON(r),
+	}
+	if cookie, err := r.Cookie(refreshCookieName); err == nil && request.RefreshToken == "" {
+		request.RefreshToken = cookie.Value
+	}
+	return request, nil
+}
+
+func encodeRefreshResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	resp, _ := response.(refreshResponse)
+	if e, ok := response.(errorer); ok && e.error() != nil {
+		if !resp.JSON {
+			clearSessionCookies(w)
+		}
+		models.ErrorEncoder(ctx, e.error(), w)
+		return nil
+	}
+
+	if resp.JSON {
+		return encodeTokens(w, resp.Tokens)
+	}
+
+	setSessionCookies(w, resp.Tokens)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// tokensResponse is the body returned to native clients, it follows the shape of an oauth2 token response.
+type tokensResponse struct {
+	AccessToken      string    `json:"access_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresIn        int64     `json:"expires_in"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+func encodeTokens(w http.ResponseWriter, tokens *SessionTokens) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if tokens == nil {
+		return json.NewEncoder(w).Encode(tokensResponse{})
+	}
+
+	// the lifetime is taken from the issue time, the time spent encoding does not shorten it.
+	return json.NewEncoder(w).Encode(tokensResponse{
+		AccessToken:      tokens.AccessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(tokens.AccessExpiresAt.Sub(tokens.AccessIssuedAt).Seconds()),
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresAt: tokens.RefreshExpiresAt,
+	})
+}
+
+// setSessionCookies stores the json web token and the refresh token of the session in the browser.
+func setSessionCookies(w http.ResponseWriter, tokens *SessionTokens) {
+	if tokens == nil {
+		return
+	}
+
+	access := &http.Cookie{
 		Name:     "mailx_google_auth",
-		Value:    resp.JWT,
+		Value:    tokens.AccessToken,
 		Path:     "/",
 		HttpOnly: true,
+		// lax cookies are not sent by cross site POST requests.
+		SameSite: http.SameSiteLaxMode,
 	}
+	refresh := &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    tokens.RefreshToken,
+		Path:     "/auth/",
+		Expires:  tokens.RefreshExpiresAt,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	}
+	w.Header().Add("Set-Cookie", access.String())
+	w.Header().Add("Set-Cookie", refresh.String())
+}
 
-	w.Header().Add("Set-Cookie", cookie.String())
-
-	redirectUrl = fmt.Sprintf("%s/success?mailx_google_success=true", viper.GetString("MAILX_APP_URL"))
-	http.Redirect(w, &http.Request{}, redirectUrl, http.StatusPermanentRedirect)
-	return nil
+func clearSessionCookies(w http.ResponseWriter) {
+	access := &http.Cookie{
+		Name:     "mailx_google_auth",
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		MaxAge:   -1,
+		SameSite: http.SameSiteLaxMode,
+	}
+	refresh := &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    "",
+		Path:     "/auth/",
+		HttpOnly: true,
+		MaxAge:   -1,
+	}
+	w.Header().Add("Set-Cookie", access.String())
+	w.Header().Add("Set-Cookie", refresh.String())
 }
 
 type errorer interface {
@@ -0,0 +1,151 @@
+package mailx
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
+)
+
+const (
+	// defaultCacheSize is the maximum number of gmail clients kept in memory.
+	defaultCacheSize = 1000
+	// defaultCacheIdleTTL evicts the gmail clients of the users that did not make a request meanwhile.
+	defaultCacheIdleTTL = 30 * time.Minute
+)
+
+// CacheStats holds the counters of the gmail clients cache.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+type cacheEntry struct {
+	userID   string
+	gmailSvc google.Service
+	lastUsed time.Time
+}
+
+/*
+clientCache is a LRU cache of gmail clients by google user ID. The least recently used client is evicted
+when the cache is full and a client is evicted after being idle for idleTTL. The expired clients are
+evicted lazily on reads and writes, the list is ordered by last use so they are always at its back.
+*/
+type clientCache struct {
+	mu      sync.Mutex
+	maxSize int
+	idleTTL time.Duration
+	now     func() time.Time
+	// entries holds the *cacheEntry ordered from the most to the least recently used.
+	entries *list.List
+	items   map[string]*list.Element
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+func newClientCache(maxSize int, idleTTL time.Duration) *clientCache {
+	if maxSize <= 0 {
+		maxSize = defaultCacheSize
+	}
+
+	if idleTTL <= 0 {
+		idleTTL = defaultCacheIdleTTL
+	}
+
+	return &clientCache{
+		maxSize: maxSize,
+		idleTTL: idleTTL,
+		now:     time.Now,
+		entries: list.New(),
+		items:   make(map[string]*list.Element),
+	}
+}
+
+func (c *clientCache) get(userID string) google.Service {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.evictExpired(now)
+
+	element, ok := c.items[userID]
+	if !ok {
+		c.misses++
+		return nil
+	}
+
+	c.hits++
+	entry := element.Value.(*cacheEntry)
+	entry.lastUsed = now
+	c.entries.MoveToFront(element)
+	return entry.gmailSvc
+}
+
+func (c *clientCache) add(userID string, gmailSvc google.Service) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.evictExpired(now)
+
+	if element, ok := c.items[userID]; ok {
+		entry := element.Value.(*cacheEntry)
+		entry.gmailSvc = gmailSvc
+		entry.lastUsed = now
+		c.entries.MoveToFront(element)
+		return
+	}
+
+	c.items[userID] = c.entries.PushFront(&cacheEntry{
+		userID:   userID,
+		gmailSvc: gmailSvc,
+		lastUsed: now,
+	})
+
+	for c.entries.Len() > c.maxSize {
+		c.remove(c.entries.Back())
+	}
+}
+
+func (c *clientCache) evict(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[userID]; ok {
+		c.remove(element)
+	}
+}
+
+func (c *clientCache) stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return CacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Size:      c.entries.Len(),
+	}
+}
+
+// evictExpired removes the clients idle since before now minus idleTTL, c.mu must be held.
+func (c *clientCache) evictExpired(now time.Time) {
+	for element := c.entries.Back(); element != nil; element = c.entries.Back() {
+		if now.Sub(element.Value.(*cacheEntry).lastUsed) < c.idleTTL {
+			return
+		}
+		c.remove(element)
+	}
+}
+
+// remove drops the element from the cache, c.mu must be held.
+func (c *clientCache) remove(element *list.Element) {
+	c.entries.Remove(element)
+	delete(c.items, element.Value.(*cacheEntry).userID)
+	c.evictions++
+}
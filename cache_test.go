@@ -0,0 +1,82 @@
+package mailx
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientCacheLRU(t *testing.T) {
+	cache := newClientCache(2, time.Hour)
+	first, second, third := &google.GmailService{}, &google.GmailService{}, &google.GmailService{}
+
+	cache.add("1", first)
+	cache.add("2", second)
+	// reading the first client makes the second one the least recently used.
+	assert.Equal(t, first, cache.get("1"))
+	cache.add("3", third)
+
+	assert.Nil(t, cache.get("2"))
+	assert.Equal(t, first, cache.get("1"))
+	assert.Equal(t, third, cache.get("3"))
+	assert.Equal(t, CacheStats{Hits: 3, Misses: 1, Evictions: 1, Size: 2}, cache.stats())
+}
+
+func TestClientCacheIdleTTL(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	cache := newClientCache(10, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.add("1", &google.GmailService{})
+	cache.add("2", &google.GmailService{})
+
+	now = now.Add(40 * time.Second)
+	// the read keeps the first client alive.
+	assert.NotNil(t, cache.get("1"))
+
+	now = now.Add(40 * time.Second)
+	assert.NotNil(t, cache.get("1"))
+	assert.Nil(t, cache.get("2"))
+
+	now = now.Add(2 * time.Minute)
+	assert.Nil(t, cache.get("1"))
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 2, Evictions: 2}, cache.stats())
+}
+
+func TestClientCacheReplace(t *testing.T) {
+	cache := newClientCache(1, time.Hour)
+	first, second := &google.GmailService{}, &google.GmailService{}
+
+	cache.add("1", first)
+	cache.add("1", second)
+	assert.Equal(t, second, cache.get("1"))
+	assert.Equal(t, CacheStats{Hits: 1, Size: 1}, cache.stats())
+}
+
+func TestClientCacheConcurrency(t *testing.T) {
+	cache := newClientCache(50, time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			userID := fmt.Sprint(i % 75)
+			if cache.get(userID) == nil {
+				cache.add(userID, &google.GmailService{})
+			}
+			if i%10 == 0 {
+				cache.evict(userID)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	stats := cache.stats()
+	assert.LessOrEqual(t, stats.Size, 50)
+	assert.Equal(t, uint64(100), stats.Hits+stats.Misses)
+}
@@ -3,7 +3,9 @@ package main
 import (
 	"context"
 	"database/sql"
+	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,17 +17,24 @@ import (
 	_ "github.com/lib/pq"
 	"github.com/orlandorode97/mailx-google-service"
 	"github.com/orlandorode97/mailx-google-service/auth"
+	"github.com/orlandorode97/mailx-google-service/drafts"
+	"github.com/orlandorode97/mailx-google-service/history"
 	"github.com/orlandorode97/mailx-google-service/labels"
 	"github.com/orlandorode97/mailx-google-service/messages"
+	"github.com/orlandorode97/mailx-google-service/pkg/envelope"
 	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/keyring"
+	"github.com/orlandorode97/mailx-google-service/pkg/middlewares"
 	"github.com/orlandorode97/mailx-google-service/pkg/repos"
 	repopg "github.com/orlandorode97/mailx-google-service/pkg/repos/postgres"
+	"github.com/orlandorode97/mailx-google-service/threads"
 	"github.com/orlandorode97/mailx-google-service/users"
 	"github.com/rs/cors"
 	"github.com/spf13/viper"
 )
 
 func main() {
+	flag.Parse()
 	logger := log.With(log.NewLogfmtLogger(os.Stdout), "ts", log.DefaultTimestampUTC)
 	err := setViperConfig()
 	if err != nil {
@@ -46,7 +55,22 @@ func main() {
 		return
 	}
 
-	repo := repopg.New(sqlx.NewDb(db, "postgres"))
+	cipher, err := envelope.Load(viper.GetString)
+	if err != nil {
+		logger.Log(
+			"message", "it was not possible to load the token encryption keys.",
+			"err", err.Error(),
+			"severity", "CRITICAL",
+		)
+		return
+	}
+
+	if flag.Arg(0) == "reencrypt-tokens" {
+		reencryptTokens(sqlx.NewDb(db, "postgres"), cipher, logger)
+		return
+	}
+
+	repo := repopg.New(sqlx.NewDb(db, "postgres"), cipher)
 
 	if repo == nil {
 		logger.Log(
@@ -59,17 +83,40 @@ func main() {
 	oauthConfig := google.NewConfig()
 
 	mailxSvc := mailx.New(logger, repo, oauthConfig)
+	go logCacheStats(mailxSvc, logger)
+
+	keys, err := keyring.Load(viper.GetString)
+	if err != nil {
+		logger.Log(
+			"message", "it was not possible to load the jwt keys.",
+			"err", err.Error(),
+			"severity", "CRITICAL",
+		)
+		return
+	}
+
+	denylist := auth.NewMemoryDenylist()
+	// every handler but auth and health requires an authenticated principal.
+	authenticate := middlewares.Authentication(keys, denylist)
 
-	authSvc := auth.New(logger, oauthConfig, repo, mailxSvc)
+	authSvc := auth.New(logger, oauthConfig, repo, mailxSvc, denylist, keys)
 	labelsSvc := labels.New(logger, repo, mailxSvc)
 	usersSvc := users.New(logger, repo, mailxSvc)
 	messagesSvc := messages.New(logger, repo, mailxSvc)
+	threadsSvc := threads.New(logger, repo, mailxSvc)
+	draftsSvc := drafts.New(logger, repo, mailxSvc)
+	historySvc := history.New(logger, repo, mailxSvc)
 
 	mux := http.NewServeMux()
-	mux.Handle("/labels/", labels.MakeHandler(labelsSvc, logger))
-	mux.Handle("/auth/", auth.MakeHandler(authSvc, logger))
-	mux.Handle("/users/", users.MakeHandler(usersSvc, logger))
-	mux.Handle("/messages/", messages.MakeHandler(messagesSvc, logger))
+	mux.Handle("/labels/", authenticate(labels.MakeHandler(labelsSvc, logger)))
+	authHandler := auth.MakeHandler(authSvc, logger)
+	mux.Handle("/auth/", authHandler)
+	mux.Handle("/.well-known/", authHandler)
+	mux.Handle("/users/", authenticate(users.MakeHandler(usersSvc, logger)))
+	mux.Handle("/messages/", authenticate(messages.MakeHandler(messagesSvc, logger)))
+	mux.Handle("/threads/", authenticate(threads.MakeHandler(threadsSvc, logger)))
+	mux.Handle("/drafts/", authenticate(drafts.MakeHandler(draftsSvc, logger)))
+	mux.Handle("/sync", authenticate(history.MakeHandler(historySvc, logger)))
 
 	mux.Handle("/health", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -98,8 +145,15 @@ func main() {
 	listenAndServe(server, logger)
 }
 
-// listenAndServe gracefully shutdowns the mailx-google-service
+// listenAndServe gracefully shutdowns the mailx-google-service, the requests still running when the
+// shutdown times out are canceled along with their gmail calls.
 func listenAndServe(server *http.Server, logger log.Logger) {
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+	server.BaseContext = func(net.Listener) context.Context {
+		return baseCtx
+	}
+
 	connClosed := make(chan struct{})
 	go func() {
 		sigint := make(chan os.Signal, 1)
@@ -118,6 +172,7 @@ func listenAndServe(server *http.Server, logger log.Logger) {
 				"err", err.Error(),
 				"severity", "CRITICAL",
 			)
+			cancelRequests()
 		}
 		close(connClosed)
 	}()
@@ -141,6 +196,42 @@ func listenAndServe(server *http.Server, logger log.Logger) {
 	<-connClosed
 }
 
+// logCacheStats logs the counters of the gmail services cache every five minutes.
+func logCacheStats(mailxSvc mailx.Service, logger log.Logger) {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		stats := mailxSvc.CacheStats()
+		logger.Log(
+			"message", "gmail services cache stats.",
+			"hits", stats.Hits,
+			"misses", stats.Misses,
+			"evictions", stats.Evictions,
+			"size", stats.Size,
+			"severity", "INFO",
+		)
+	}
+}
+
+// reencryptTokens encrypts the stored oauth tokens with the current key encryption key, it is run once
+// after enabling the encryption and after every rotation of the key encryption key.
+func reencryptTokens(db *sqlx.DB, cipher *envelope.Cipher, logger log.Logger) {
+	rewritten, err := repopg.ReencryptTokens(context.Background(), db, cipher)
+	if err != nil {
+		logger.Log(
+			"message", fmt.Sprintf("it was not possible to re-encrypt the tokens, %d rows were re-encrypted.", rewritten),
+			"err", err.Error(),
+			"severity", "CRITICAL",
+		)
+		os.Exit(1)
+	}
+
+	logger.Log(
+		"message", fmt.Sprintf("%d rows were re-encrypted with the key %s.", rewritten, cipher.CurrentID()),
+		"severity", "NOTICE",
+	)
+}
+
 func setViperConfig() error {
 	viper.AddConfigPath(".")
 	viper.SetConfigFile(".env")
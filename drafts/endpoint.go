@@ -0,0 +1,173 @@
+package drafts
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"google.golang.org/api/gmail/v1"
+)
+
+type Endpoints struct {
+	CreateDraftEndpoint  endpoint.Endpoint
+	UpdateDraftEndpoint  endpoint.Endpoint
+	GetDraftsEndpoint    endpoint.Endpoint
+	GetDraftByIDEndpoint endpoint.Endpoint
+	DeleteDraftEndpoint  endpoint.Endpoint
+	SendDraftEndpoint    endpoint.Endpoint
+}
+
+func MakeEndpoints(s Service) Endpoints {
+	return Endpoints{
+		CreateDraftEndpoint:  MakeCreateDraft(s),
+		UpdateDraftEndpoint:  MakeUpdateDraft(s),
+		GetDraftsEndpoint:    MakeGetDrafts(s),
+		GetDraftByIDEndpoint: MakeGetDraftByID(s),
+		DeleteDraftEndpoint:  MakeDeleteDraft(s),
+		SendDraftEndpoint:    MakeSendDraft(s),
+	}
+}
+
+func MakeCreateDraft(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(draftMessageRequest)
+		draft, err := s.CreateDraft(ctx, req.UserID, req.Message)
+		if err != nil {
+			return draftResponse{
+				Err: err,
+			}, nil
+		}
+		return draftResponse{
+			Draft: draft,
+		}, nil
+	}
+}
+
+func MakeUpdateDraft(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(draftMessageRequest)
+		draft, err := s.UpdateDraft(ctx, req.UserID, req.DraftID, req.Message)
+		if err != nil {
+			return draftResponse{
+				Err: err,
+			}, nil
+		}
+		return draftResponse{
+			Draft: draft,
+		}, nil
+	}
+}
+
+func MakeGetDrafts(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(getDraftsRequest)
+		page, err := s.GetDrafts(ctx, req.UserID, req.Options)
+		if err != nil {
+			return getDraftsResponse{
+				Err: err,
+			}, nil
+		}
+		return getDraftsResponse{
+			Drafts:             page.Drafts,
+			NextPageToken:      page.NextPageToken,
+			ResultSizeEstimate: page.ResultSizeEstimate,
+		}, nil
+	}
+}
+
+func MakeGetDraftByID(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(draftByIDRequest)
+		draft, err := s.GetDraftByID(ctx, req.UserID, req.DraftID)
+		if err != nil {
+			return draftResponse{
+				Err: err,
+			}, nil
+		}
+		req.Format.Apply(draft.Message)
+		return draftResponse{
+			Draft: draft,
+		}, nil
+	}
+}
+
+func MakeDeleteDraft(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(draftByIDRequest)
+		if err := s.DeleteDraft(ctx, req.UserID, req.DraftID); err != nil {
+			return deleteDraftResponse{Err: err}, nil
+		}
+
+		return deleteDraftResponse{}, nil
+	}
+}
+
+func MakeSendDraft(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(draftByIDRequest)
+		message, err := s.SendDraft(ctx, req.UserID, req.DraftID)
+		if err != nil {
+			return sendDraftResponse{
+				Err: err,
+			}, nil
+		}
+		return sendDraftResponse{
+			Message: message,
+		}, nil
+	}
+}
+
+type draftMessageRequest struct {
+	UserID  string
+	DraftID string
+	Message *models.OutgoingMessage
+}
+
+type draftByIDRequest struct {
+	UserID  string
+	DraftID string
+	Format  models.MessageFormat
+}
+
+type draftResponse struct {
+	Draft *models.Draft `json:"draft"`
+	Err   error         `json:"error,omitempty"`
+}
+
+func (d draftResponse) error() error {
+	return d.Err
+}
+
+type getDraftsRequest struct {
+	UserID  string
+	Options google.MessageListOptions
+}
+
+type getDraftsResponse struct {
+	Drafts             []*models.Draft `json:"drafts"`
+	NextPageToken      string          `json:"next_page_token,omitempty"`
+	ResultSizeEstimate int64           `json:"result_size_estimate"`
+	Err                error           `json:"error,omitempty"`
+}
+
+func (g getDraftsResponse) error() error {
+	return g.Err
+}
+
+type deleteDraftResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (d deleteDraftResponse) error() error {
+	return d.Err
+}
+
+type sendDraftResponse struct {
+	Message *gmail.Message `json:"message"`
+	Err     error          `json:"error,omitempty"`
+}
+
+func (s sendDraftResponse) error() error {
+	return s.Err
+}
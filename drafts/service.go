@@ -0,0 +1,223 @@
+package drafts
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/go-kit/log"
+	"github.com/orlandorode97/mailx-google-service"
+	"github.com/orlandorode97/mailx-google-service/messages"
+	"github.com/orlandorode97/mailx-google-service/pkg/composer"
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/orlandorode97/mailx-google-service/pkg/repos"
+	"google.golang.org/api/gmail/v1"
+)
+
+const (
+	// draftsLimit is the default page size when a client does not request one.
+	draftsLimit int64 = 10
+	// maxDraftsLimit caps the page size to the maximum accepted by gmail.
+	maxDraftsLimit int64 = 500
+)
+
+type Service interface {
+	// CreateDraft composes the outgoing message and saves it as a new draft, recipients are optional.
+	CreateDraft(context.Context, string, *models.OutgoingMessage) (*models.Draft, error)
+	// UpdateDraft replaces the whole content of a draft.
+	UpdateDraft(context.Context, string, string, *models.OutgoingMessage) (*models.Draft, error)
+	GetDrafts(context.Context, string, google.MessageListOptions) (*models.DraftsPage, error)
+	GetDraftByID(context.Context, string, string) (*models.Draft, error)
+	DeleteDraft(context.Context, string, string) error
+	// SendDraft sends a draft to its recipients, gmail deletes the draft once it is sent.
+	SendDraft(context.Context, string, string) (*gmail.Message, error)
+}
+
+type service struct {
+	logger   log.Logger
+	repo     repos.Repository
+	mailxSvc mailx.Service
+}
+
+func New(logger log.Logger, repo repos.Repository, mailx mailx.Service) Service {
+	return &service{
+		logger:   logger,
+		repo:     repo,
+		mailxSvc: mailx,
+	}
+}
+
+// draftService returns the drafts service attached to the user, recreating the gmail service when it is missing.
+func (s *service) draftService(ctx context.Context, userID string) (google.Drafter, error) {
+	svc := s.mailxSvc.GetGmailService(userID)
+	if svc == nil || (reflect.ValueOf(svc).Kind() == reflect.Ptr && reflect.ValueOf(svc).IsNil()) {
+		var err error
+		svc, err = s.mailxSvc.RecreateGmailService(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return svc.GetDraftsService(), nil
+}
+
+func (s *service) CreateDraft(ctx context.Context, userID string, msg *models.OutgoingMessage) (*models.Draft, error) {
+	raw, err := composer.RawDraft(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, err := s.draftService(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	draft, err := svc.Create(ctx, userID, &gmail.Draft{Message: &gmail.Message{Raw: raw, ThreadId: msg.ThreadID}}).Do()
+	if err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error creating draft for user=%s", userID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return nil, err
+	}
+
+	return hydrateDraft(draft)
+}
+
+func (s *service) UpdateDraft(ctx context.Context, userID string, draftID string, msg *models.OutgoingMessage) (*models.Draft, error) {
+	raw, err := composer.RawDraft(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, err := s.draftService(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	draft, err := svc.Update(ctx, userID, draftID, &gmail.Draft{Id: draftID, Message: &gmail.Message{Raw: raw, ThreadId: msg.ThreadID}}).Do()
+	if err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error updating draft=%s for user=%s", draftID, userID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return nil, err
+	}
+
+	return hydrateDraft(draft)
+}
+
+func (s *service) GetDrafts(ctx context.Context, userID string, opts google.MessageListOptions) (*models.DraftsPage, error) {
+	switch {
+	case opts.MaxResults < 0 || opts.MaxResults > maxDraftsLimit:
+		return nil, models.ErrInvalidData{Field: "page_size"}
+	case opts.MaxResults == 0:
+		opts.MaxResults = draftsLimit
+	}
+
+	svc, err := s.draftService(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	draftsResp, err := svc.List(ctx, userID, opts).Do()
+	if err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error getting drafts for user=%s", userID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return nil, err
+	}
+
+	drafts := make([]*models.Draft, 0, len(draftsResp.Drafts))
+	for _, draft := range draftsResp.Drafts {
+		d, err := hydrateDraft(draft)
+		if err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, d)
+	}
+
+	return &models.DraftsPage{
+		Drafts:             drafts,
+		NextPageToken:      draftsResp.NextPageToken,
+		ResultSizeEstimate: draftsResp.ResultSizeEstimate,
+	}, nil
+}
+
+func (s *service) GetDraftByID(ctx context.Context, userID string, draftID string) (*models.Draft, error) {
+	svc, err := s.draftService(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	draft, err := svc.Get(ctx, userID, draftID).Do()
+	if err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error getting draft=%s for user=%s", draftID, userID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return nil, err
+	}
+
+	return hydrateDraft(draft)
+}
+
+func (s *service) DeleteDraft(ctx context.Context, userID string, draftID string) error {
+	svc, err := s.draftService(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := svc.Delete(ctx, userID, draftID).Do(); err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error deleting draft=%s for user=%s", draftID, userID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (s *service) SendDraft(ctx context.Context, userID string, draftID string) (*gmail.Message, error) {
+	svc, err := s.draftService(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := svc.Send(ctx, userID, &gmail.Draft{Id: draftID}).Do()
+	if err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error sending draft=%s for user=%s", draftID, userID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return nil, err
+	}
+
+	s.logger.Log(
+		"message", fmt.Sprintf("draft=%s sent as message=%s for user=%s", draftID, message.Id, userID),
+		"severity", "INFO",
+	)
+	return message, nil
+}
+
+func hydrateDraft(draft *gmail.Draft) (*models.Draft, error) {
+	d := &models.Draft{ID: draft.Id}
+	if draft.Message == nil {
+		return d, nil
+	}
+
+	message, err := messages.HydrateMessage(draft.Message)
+	if err != nil {
+		return nil, err
+	}
+	d.Message = message
+	return d, nil
+}
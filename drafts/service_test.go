@@ -0,0 +1,375 @@
+package drafts
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/orlandorode97/mailx-google-service"
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+type MockGmailService struct {
+	mock.Mock
+}
+
+func (m *MockGmailService) GetLabelsService() google.Labeler {
+	args := m.Called()
+	return args.Get(0).(google.Labeler)
+}
+
+func (m *MockGmailService) GetMessagesService() google.Messenger {
+	args := m.Called()
+	return args.Get(0).(google.Messenger)
+}
+
+func (m *MockGmailService) GetAttachmentsService() google.Attacher {
+	args := m.Called()
+	return args.Get(0).(google.Attacher)
+}
+
+func (m *MockGmailService) GetThreadsService() google.Threader {
+	args := m.Called()
+	return args.Get(0).(google.Threader)
+}
+
+func (m *MockGmailService) GetDraftsService() google.Drafter {
+	args := m.Called()
+	return args.Get(0).(google.Drafter)
+}
+
+func (m *MockGmailService) GetHistoryService() google.Historian {
+	args := m.Called()
+	return args.Get(0).(google.Historian)
+}
+
+type MockMailxService struct {
+	mock.Mock
+}
+
+func (m *MockMailxService) GetGmailService(userID string) google.Service {
+	args := m.Called(userID)
+	return args.Get(0).(google.Service)
+}
+
+func (m *MockMailxService) CreateGmailService(ctx context.Context, userID string, token *oauth2.Token) (google.Service, error) {
+	args := m.Called(ctx, userID, token)
+	return args.Get(0).(google.Service), args.Error(1)
+}
+
+func (m *MockMailxService) AddGmailServiceByID(ID string, gmailSvc google.Service) google.Service {
+	args := m.Called(ID, gmailSvc)
+	return args.Get(0).(google.Service)
+}
+
+func (m *MockMailxService) Evict(ID string) {
+	m.Called(ID)
+}
+
+func (m *MockMailxService) CacheStats() mailx.CacheStats {
+	args := m.Called()
+	return args.Get(0).(mailx.CacheStats)
+}
+
+func (m *MockMailxService) RecreateGmailService(ctx context.Context, ID string) (google.Service, error) {
+	args := m.Called(ctx, ID)
+	return args.Get(0).(google.Service), args.Error(1)
+}
+
+type MockDrafter struct {
+	mock.Mock
+}
+
+func (m *MockDrafter) Create(ctx context.Context, userID string, draft *gmail.Draft) google.DrafterClientResp {
+	args := m.Called(ctx, userID, draft)
+	return args.Get(0).(google.DrafterClientResp)
+}
+
+func (m *MockDrafter) Delete(ctx context.Context, userID string, draftID string) google.DrafterClient {
+	args := m.Called(ctx, userID, draftID)
+	return args.Get(0).(google.DrafterClient)
+}
+
+func (m *MockDrafter) Get(ctx context.Context, userID string, draftID string) google.DrafterClientResp {
+	args := m.Called(ctx, userID, draftID)
+	return args.Get(0).(google.DrafterClientResp)
+}
+
+func (m *MockDrafter) List(ctx context.Context, userID string, opts google.MessageListOptions) google.DrafterClientList {
+	args := m.Called(ctx, userID, opts)
+	return args.Get(0).(google.DrafterClientList)
+}
+
+func (m *MockDrafter) Send(ctx context.Context, userID string, draft *gmail.Draft) google.DrafterClientSend {
+	args := m.Called(ctx, userID, draft)
+	return args.Get(0).(google.DrafterClientSend)
+}
+
+func (m *MockDrafter) Update(ctx context.Context, userID string, draftID string, draft *gmail.Draft) google.DrafterClientResp {
+	args := m.Called(ctx, userID, draftID, draft)
+	return args.Get(0).(google.DrafterClientResp)
+}
+
+type MockDrafterClient struct {
+	mock.Mock
+}
+
+func (m *MockDrafterClient) Do(opts ...googleapi.CallOption) error {
+	args := m.Called(opts)
+	return args.Error(0)
+}
+
+type MockDrafterClientResp struct {
+	mock.Mock
+}
+
+func (m *MockDrafterClientResp) Do(opts ...googleapi.CallOption) (*gmail.Draft, error) {
+	args := m.Called(opts)
+	return args.Get(0).(*gmail.Draft), args.Error(1)
+}
+
+type MockDrafterClientList struct {
+	mock.Mock
+}
+
+func (m *MockDrafterClientList) Do(opts ...googleapi.CallOption) (*gmail.ListDraftsResponse, error) {
+	args := m.Called(opts)
+	return args.Get(0).(*gmail.ListDraftsResponse), args.Error(1)
+}
+
+type MockDrafterClientSend struct {
+	mock.Mock
+}
+
+func (m *MockDrafterClientSend) Do(opts ...googleapi.CallOption) (*gmail.Message, error) {
+	args := m.Called(opts)
+	return args.Get(0).(*gmail.Message), args.Error(1)
+}
+
+// newDraftsService returns a drafts service whose gmail service is cached for the user.
+func newDraftsService(userID string, drafter *MockDrafter) Service {
+	mockGmailService := &MockGmailService{}
+	mailxSvc := &MockMailxService{}
+	mockGmailService.On("GetDraftsService").Return(drafter)
+	mailxSvc.On("GetGmailService", userID).Return(mockGmailService)
+	return New(log.NewLogfmtLogger(os.Stdin), nil, mailxSvc)
+}
+
+// rawDraft decodes the raw message sent to gmail.
+func rawDraft(t *testing.T, draft *gmail.Draft) string {
+	raw, err := base64.URLEncoding.DecodeString(draft.Message.Raw)
+	if err != nil {
+		t.Fatalf("cannot decode raw draft: %v", err)
+	}
+	return string(raw)
+}
+
+func TestCreateDraft(t *testing.T) {
+	testcases := []struct {
+		name          string
+		userID        string
+		message       *models.OutgoingMessage
+		draft         *gmail.Draft
+		errCreate     error
+		expectedErr   error
+		assertErr     func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+		expectedDraft *models.Draft
+	}{
+		{
+			name:    "success - draft without recipients is saved.",
+			userID:  "1",
+			message: &models.OutgoingMessage{Subject: "work in progress", Text: "hello"},
+			draft: &gmail.Draft{
+				Id:      "r-1",
+				Message: &gmail.Message{Id: "MESSAGE_1", ThreadId: "THREAD_1"},
+			},
+			assertErr: assert.Nil,
+			expectedDraft: &models.Draft{
+				ID:      "r-1",
+				Message: &models.Message{ID: "MESSAGE_1", ThreadID: "THREAD_1", Attachments: []*models.MessageAttachment{}},
+			},
+		},
+		{
+			name:        "failure - recipient address is invalid.",
+			userID:      "1",
+			message:     &models.OutgoingMessage{To: []models.Address{{Email: "not an email"}}},
+			expectedErr: models.ErrInvalidData{Field: "to"},
+			assertErr:   assert.NotNil,
+		},
+		{
+			name:      "failure - gmail drafts service responds an error.",
+			userID:    "1",
+			message:   &models.OutgoingMessage{Text: "hello"},
+			draft:     &gmail.Draft{},
+			errCreate: errors.New("quota exceeded"),
+			assertErr: assert.NotNil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			drafter := &MockDrafter{}
+			call := &MockDrafterClientResp{}
+			call.On("Do", []googleapi.CallOption(nil)).Return(test.draft, test.errCreate)
+			drafter.On("Create", mock.Anything, test.userID, mock.MatchedBy(func(draft *gmail.Draft) bool {
+				raw := rawDraft(t, draft)
+				return strings.Contains(raw, test.message.Subject) && !strings.Contains(raw, "To:")
+			})).Return(call)
+
+			draft, err := newDraftsService(test.userID, drafter).CreateDraft(context.Background(), test.userID, test.message)
+			test.assertErr(t, err)
+			if test.expectedErr != nil {
+				assert.Equal(t, test.expectedErr, err)
+			}
+			assert.Equal(t, test.expectedDraft, draft)
+		})
+	}
+}
+
+func TestUpdateDraft(t *testing.T) {
+	t.Run("success - draft content is replaced.", func(t *testing.T) {
+		drafter := &MockDrafter{}
+		call := &MockDrafterClientResp{}
+		call.On("Do", []googleapi.CallOption(nil)).Return(&gmail.Draft{Id: "r-1", Message: &gmail.Message{Id: "MESSAGE_2"}}, nil)
+		drafter.On("Update", mock.Anything, "1", "r-1", mock.MatchedBy(func(draft *gmail.Draft) bool {
+			return draft.Id == "r-1" && strings.Contains(rawDraft(t, draft), "To: <jose@example.com>")
+		})).Return(call)
+
+		draft, err := newDraftsService("1", drafter).UpdateDraft(context.Background(), "1", "r-1", &models.OutgoingMessage{
+			To:   []models.Address{{Email: "jose@example.com"}},
+			Text: "final version",
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "MESSAGE_2", draft.Message.ID)
+	})
+}
+
+func TestGetDrafts(t *testing.T) {
+	testcases := []struct {
+		name         string
+		opts         google.MessageListOptions
+		expectedOpts google.MessageListOptions
+		listResponse *gmail.ListDraftsResponse
+		errList      error
+		assertErr    func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+		expectedPage *models.DraftsPage
+	}{
+		{
+			name:         "success - default page size is used when it is not requested.",
+			expectedOpts: google.MessageListOptions{MaxResults: draftsLimit},
+			listResponse: &gmail.ListDraftsResponse{
+				Drafts:             []*gmail.Draft{{Id: "r-1", Message: &gmail.Message{Id: "MESSAGE_1", ThreadId: "THREAD_1"}}},
+				NextPageToken:      "page-2",
+				ResultSizeEstimate: 12,
+			},
+			assertErr: assert.Nil,
+			expectedPage: &models.DraftsPage{
+				Drafts:             []*models.Draft{{ID: "r-1", Message: &models.Message{ID: "MESSAGE_1", ThreadID: "THREAD_1", Attachments: []*models.MessageAttachment{}}}},
+				NextPageToken:      "page-2",
+				ResultSizeEstimate: 12,
+			},
+		},
+		{
+			name:         "failure - page size exceeds the limit.",
+			opts:         google.MessageListOptions{MaxResults: maxDraftsLimit + 1},
+			listResponse: &gmail.ListDraftsResponse{},
+			assertErr:    assert.NotNil,
+		},
+		{
+			name:         "failure - gmail drafts service responds an error.",
+			expectedOpts: google.MessageListOptions{MaxResults: draftsLimit},
+			listResponse: &gmail.ListDraftsResponse{},
+			errList:      errors.New("invalid page token"),
+			assertErr:    assert.NotNil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			drafter := &MockDrafter{}
+			call := &MockDrafterClientList{}
+			call.On("Do", []googleapi.CallOption(nil)).Return(test.listResponse, test.errList)
+			drafter.On("List", mock.Anything, "1", test.expectedOpts).Return(call)
+
+			page, err := newDraftsService("1", drafter).GetDrafts(context.Background(), "1", test.opts)
+			test.assertErr(t, err)
+			assert.Equal(t, test.expectedPage, page)
+		})
+	}
+}
+
+func TestDeleteDraft(t *testing.T) {
+	testcases := []struct {
+		name      string
+		errDelete error
+		assertErr func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+	}{
+		{
+			name:      "success - draft is deleted.",
+			assertErr: assert.Nil,
+		},
+		{
+			name:      "failure - gmail drafts service responds an error.",
+			errDelete: errors.New("not found"),
+			assertErr: assert.NotNil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			drafter := &MockDrafter{}
+			call := &MockDrafterClient{}
+			call.On("Do", []googleapi.CallOption(nil)).Return(test.errDelete)
+			drafter.On("Delete", mock.Anything, "1", "r-1").Return(call)
+
+			err := newDraftsService("1", drafter).DeleteDraft(context.Background(), "1", "r-1")
+			test.assertErr(t, err)
+		})
+	}
+}
+
+func TestSendDraft(t *testing.T) {
+	testcases := []struct {
+		name      string
+		message   *gmail.Message
+		errSend   error
+		assertErr func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+	}{
+		{
+			name:      "success - draft is sent by its id.",
+			message:   &gmail.Message{Id: "MESSAGE_1", LabelIds: []string{"SENT"}},
+			assertErr: assert.Nil,
+		},
+		{
+			name:      "failure - draft has no recipients.",
+			message:   &gmail.Message{},
+			errSend:   errors.New("invalid to header"),
+			assertErr: assert.NotNil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			drafter := &MockDrafter{}
+			call := &MockDrafterClientSend{}
+			call.On("Do", []googleapi.CallOption(nil)).Return(test.message, test.errSend)
+			drafter.On("Send", mock.Anything, "1", &gmail.Draft{Id: "r-1"}).Return(call)
+
+			message, err := newDraftsService("1", drafter).SendDraft(context.Background(), "1", "r-1")
+			test.assertErr(t, err)
+			if err == nil {
+				assert.Equal(t, test.message, message)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package drafts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/orlandorode97/mailx-google-service/pkg/middlewares"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/orlandorode97/mailx-google-service/pkg/transport"
+)
+
+func MakeHandler(draftsService Service, logger log.Logger) http.Handler {
+	r := mux.NewRouter()
+
+	e := MakeEndpoints(draftsService)
+	options := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(models.ErrorEncoder),
+	}
+	r.Methods(http.MethodPost).
+		Path("/drafts/").
+		Handler(kithttp.NewServer(
+			e.CreateDraftEndpoint,
+			decodeDraftMessageRequest,
+			encodeDraftResponse,
+			options...,
+		))
+
+	r.Methods(http.MethodGet).
+		Path("/drafts/").
+		Handler(kithttp.NewServer(
+			e.GetDraftsEndpoint,
+			decodeDraftsRequest,
+			encodeDraftResponse,
+			options...,
+		))
+
+	r.Methods(http.MethodPost).
+		Path("/drafts/{draft_id:[0-9a-zA-Z_\\-]+}/send").
+		Handler(kithttp.NewServer(
+			e.SendDraftEndpoint,
+			decodeDraftByIDRequest,
+			encodeDraftResponse,
+			options...,
+		))
+
+	r.Methods(http.MethodGet).
+		Path("/drafts/{draft_id:[0-9a-zA-Z_\\-]+}").
+		Handler(kithttp.NewServer(
+			e.GetDraftByIDEndpoint,
+			decodeDraftByIDRequest,
+			encodeDraftResponse,
+			options...,
+		))
+
+	r.Methods(http.MethodPut).
+		Path("/drafts/{draft_id:[0-9a-zA-Z_\\-]+}").
+		Handler(kithttp.NewServer(
+			e.UpdateDraftEndpoint,
+			decodeDraftMessageRequest,
+			encodeDraftResponse,
+			options...,
+		))
+
+	r.Methods(http.MethodDelete).
+		Path("/drafts/{draft_id:[0-9a-zA-Z_\\-]+}").
+		Handler(kithttp.NewServer(
+			e.DeleteDraftEndpoint,
+			decodeDraftByIDRequest,
+			encodeDeleteDraftResponse,
+			options...,
+		))
+
+	return r
+}
+
+// decodeDraftMessageRequest decodes the create and update requests, the draft id is only present when updating.
+func decodeDraftMessageRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	userID, err := middlewares.UserIDFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var message models.OutgoingMessage
+	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+		return nil, models.ErrInvalidData{Field: "message"}
+	}
+
+	return draftMessageRequest{
+		UserID:  userID,
+		DraftID: mux.Vars(r)["draft_id"],
+		Message: &message,
+	}, nil
+}
+
+func decodeDraftsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	userID, err := middlewares.UserIDFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := transport.ParseMessageListOptions(r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+	// the drafts list call has no label filter.
+	opts.LabelIDs = nil
+
+	return getDraftsRequest{
+		UserID:  userID,
+		Options: opts,
+	}, nil
+}
+
+func decodeDraftByIDRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	userID, err := middlewares.UserIDFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	draftID := mux.Vars(r)["draft_id"]
+	if draftID == "" {
+		return nil, models.ErrInvalidData{Field: "draft_id"}
+	}
+
+	format, err := models.ParseMessageFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		return nil, err
+	}
+
+	return draftByIDRequest{
+		UserID:  userID,
+		DraftID: draftID,
+		Format:  format,
+	}, nil
+}
+
+func encodeDraftResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	if e, ok := response.(errorer); ok && e.error() != nil {
+		return e.error()
+	}
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+func encodeDeleteDraftResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	if e, ok := response.(errorer); ok && e.error() != nil {
+		return e.error()
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+type errorer interface {
+	error() error
+}
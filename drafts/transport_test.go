@@ -0,0 +1,60 @@
+package drafts
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/orlandorode97/mailx-google-service/pkg/middlewares"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeDraftMessageRequest(t *testing.T) {
+	testcases := []struct {
+		name            string
+		body            string
+		draftID         string
+		expectedRequest interface{}
+		expectedErr     error
+	}{
+		{
+			name:    "success - update request carries the draft id.",
+			body:    `{"to":[{"email":"jose@example.com"}],"subject":"hello"}`,
+			draftID: "r-1",
+			expectedRequest: draftMessageRequest{
+				UserID:  "1",
+				DraftID: "r-1",
+				Message: &models.OutgoingMessage{To: []models.Address{{Email: "jose@example.com"}}, Subject: "hello"},
+			},
+		},
+		{
+			name:        "failure - body is not json.",
+			body:        "hello",
+			expectedErr: models.ErrInvalidData{Field: "message"},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/drafts/"+test.draftID, strings.NewReader(test.body))
+			req = req.WithContext(middlewares.WithPrincipal(req.Context(), &middlewares.Principal{UserID: "1"}))
+			req = mux.SetURLVars(req, map[string]string{"draft_id": test.draftID})
+			request, err := decodeDraftMessageRequest(context.Background(), req)
+			assert.Equal(t, test.expectedErr, err)
+			assert.Equal(t, test.expectedRequest, request)
+		})
+	}
+}
+
+func TestDecodeDraftByIDRequest(t *testing.T) {
+	t.Run("failure - missing draft id.", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/drafts/", nil)
+		req = req.WithContext(middlewares.WithPrincipal(req.Context(), &middlewares.Principal{UserID: "1"}))
+		_, err := decodeDraftByIDRequest(context.Background(), req)
+		assert.Equal(t, models.ErrInvalidData{Field: "draft_id"}, err)
+	})
+}
@@ -0,0 +1,64 @@
+package mailx
+
+import (
+	"context"
+	"sync"
+
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
+)
+
+// flight is an in-progress or completed call of a flightGroup.
+type flight struct {
+	done     chan struct{}
+	gmailSvc google.Service
+	err      error
+}
+
+// flightGroup collapses the concurrent calls with the same key into a single execution,
+// every caller gets the result or the error of that execution. The zero value is ready to use.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+	// joined is called with the mutex held once a caller is part of a flight, tests use it to know
+	// when every caller has joined.
+	joined func(key string)
+}
+
+/*
+do runs fn once for all the concurrent callers of the key. fn runs detached from the ctx of the callers,
+one caller giving up must not fail the others, so a caller whose ctx is done stops waiting and gets
+the ctx error while fn keeps running for the rest.
+*/
+func (g *flightGroup) do(ctx context.Context, key string, fn func() (google.Service, error)) (google.Service, error) {
+	g.mu.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+
+	f, ok := g.flights[key]
+	if !ok {
+		f = &flight{done: make(chan struct{})}
+		g.flights[key] = f
+		go g.run(key, f, fn)
+	}
+	if g.joined != nil {
+		g.joined(key)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.gmailSvc, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (g *flightGroup) run(key string, f *flight, fn func() (google.Service, error)) {
+	f.gmailSvc, f.err = fn()
+
+	g.mu.Lock()
+	delete(g.flights, key)
+	g.mu.Unlock()
+	close(f.done)
+}
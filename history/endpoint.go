@@ -0,0 +1,48 @@
+package history
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+)
+
+type Endpoints struct {
+	SyncEndpoint endpoint.Endpoint
+}
+
+func MakeEndpoints(s Service) Endpoints {
+	return Endpoints{
+		SyncEndpoint: MakeSync(s),
+	}
+}
+
+func MakeSync(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(syncRequest)
+		changes, err := s.Sync(ctx, req.UserID, req.Options)
+		if err != nil {
+			return syncResponse{
+				Err: err,
+			}, nil
+		}
+		return syncResponse{
+			MailboxChanges: changes,
+		}, nil
+	}
+}
+
+type syncRequest struct {
+	UserID  string
+	Options google.HistoryListOptions
+}
+
+type syncResponse struct {
+	*models.MailboxChanges
+	Err error `json:"error,omitempty"`
+}
+
+func (s syncResponse) error() error {
+	return s.Err
+}
@@ -0,0 +1,123 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/go-kit/log"
+	"github.com/orlandorode97/mailx-google-service"
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/orlandorode97/mailx-google-service/pkg/repos"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	// historyLimit is the number of history records requested per page.
+	historyLimit int64 = 100
+)
+
+type Service interface {
+	// Sync returns the mailbox changes since the history id. Clients must keep the returned history id
+	// only once the last page has been read.
+	Sync(context.Context, string, google.HistoryListOptions) (*models.MailboxChanges, error)
+}
+
+type service struct {
+	logger   log.Logger
+	repo     repos.Repository
+	mailxSvc mailx.Service
+}
+
+func New(logger log.Logger, repo repos.Repository, mailx mailx.Service) Service {
+	return &service{
+		logger:   logger,
+		repo:     repo,
+		mailxSvc: mailx,
+	}
+}
+
+// historyService returns the history service attached to the user, recreating the gmail service when it is missing.
+func (s *service) historyService(ctx context.Context, userID string) (google.Historian, error) {
+	svc := s.mailxSvc.GetGmailService(userID)
+	if svc == nil || (reflect.ValueOf(svc).Kind() == reflect.Ptr && reflect.ValueOf(svc).IsNil()) {
+		var err error
+		svc, err = s.mailxSvc.RecreateGmailService(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return svc.GetHistoryService(), nil
+}
+
+func (s *service) Sync(ctx context.Context, userID string, opts google.HistoryListOptions) (*models.MailboxChanges, error) {
+	if opts.StartHistoryID == 0 {
+		return nil, models.ErrInvalidData{Field: "since_history_id"}
+	}
+	if opts.MaxResults == 0 {
+		opts.MaxResults = historyLimit
+	}
+
+	svc, err := s.historyService(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	historyResp, err := svc.List(ctx, userID, opts).Do()
+	if err != nil {
+		// gmail answers 404 when the start history id is older than the history it keeps, usually a week.
+		var gErr *googleapi.Error
+		if errors.As(err, &gErr) && gErr.Code == http.StatusNotFound {
+			s.logger.Log(
+				"message", fmt.Sprintf("history id=%d expired for user=%s", opts.StartHistoryID, userID),
+				"severity", "INFO",
+			)
+			return nil, models.ErrHistoryExpired{}
+		}
+
+		s.logger.Log(
+			"message", fmt.Sprintf("error getting history for user=%s", userID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return nil, err
+	}
+
+	changes := &models.MailboxChanges{
+		HistoryID:       historyResp.HistoryId,
+		NextPageToken:   historyResp.NextPageToken,
+		MessagesAdded:   make([]*models.MessageChange, 0),
+		MessagesDeleted: make([]*models.MessageChange, 0),
+		LabelsAdded:     make([]*models.MessageChange, 0),
+		LabelsRemoved:   make([]*models.MessageChange, 0),
+	}
+
+	for _, history := range historyResp.History {
+		for _, added := range history.MessagesAdded {
+			changes.MessagesAdded = append(changes.MessagesAdded, messageChange(added.Message, added.Message.LabelIds))
+		}
+		for _, deleted := range history.MessagesDeleted {
+			changes.MessagesDeleted = append(changes.MessagesDeleted, messageChange(deleted.Message, nil))
+		}
+		for _, labelAdded := range history.LabelsAdded {
+			changes.LabelsAdded = append(changes.LabelsAdded, messageChange(labelAdded.Message, labelAdded.LabelIds))
+		}
+		for _, labelRemoved := range history.LabelsRemoved {
+			changes.LabelsRemoved = append(changes.LabelsRemoved, messageChange(labelRemoved.Message, labelRemoved.LabelIds))
+		}
+	}
+
+	return changes, nil
+}
+
+func messageChange(message *gmail.Message, labelIDs []string) *models.MessageChange {
+	return &models.MessageChange{
+		ID:       message.Id,
+		ThreadID: message.ThreadId,
+		LabelIDs: labelIDs,
+	}
+}
@@ -0,0 +1,219 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/orlandorode97/mailx-google-service"
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+type MockGmailService struct {
+	mock.Mock
+}
+
+func (m *MockGmailService) GetLabelsService() google.Labeler {
+	args := m.Called()
+	return args.Get(0).(google.Labeler)
+}
+
+func (m *MockGmailService) GetMessagesService() google.Messenger {
+	args := m.Called()
+	return args.Get(0).(google.Messenger)
+}
+
+func (m *MockGmailService) GetAttachmentsService() google.Attacher {
+	args := m.Called()
+	return args.Get(0).(google.Attacher)
+}
+
+func (m *MockGmailService) GetThreadsService() google.Threader {
+	args := m.Called()
+	return args.Get(0).(google.Threader)
+}
+
+func (m *MockGmailService) GetDraftsService() google.Drafter {
+	args := m.Called()
+	return args.Get(0).(google.Drafter)
+}
+
+func (m *MockGmailService) GetHistoryService() google.Historian {
+	args := m.Called()
+	return args.Get(0).(google.Historian)
+}
+
+type MockMailxService struct {
+	mock.Mock
+}
+
+func (m *MockMailxService) GetGmailService(userID string) google.Service {
+	args := m.Called(userID)
+	return args.Get(0).(google.Service)
+}
+
+func (m *MockMailxService) CreateGmailService(ctx context.Context, userID string, token *oauth2.Token) (google.Service, error) {
+	args := m.Called(ctx, userID, token)
+	return args.Get(0).(google.Service), args.Error(1)
+}
+
+func (m *MockMailxService) AddGmailServiceByID(ID string, gmailSvc google.Service) google.Service {
+	args := m.Called(ID, gmailSvc)
+	return args.Get(0).(google.Service)
+}
+
+func (m *MockMailxService) Evict(ID string) {
+	m.Called(ID)
+}
+
+func (m *MockMailxService) CacheStats() mailx.CacheStats {
+	args := m.Called()
+	return args.Get(0).(mailx.CacheStats)
+}
+
+func (m *MockMailxService) RecreateGmailService(ctx context.Context, ID string) (google.Service, error) {
+	args := m.Called(ctx, ID)
+	return args.Get(0).(google.Service), args.Error(1)
+}
+
+type MockHistorian struct {
+	mock.Mock
+}
+
+func (m *MockHistorian) List(ctx context.Context, userID string, opts google.HistoryListOptions) google.HistorianClientList {
+	args := m.Called(ctx, userID, opts)
+	return args.Get(0).(google.HistorianClientList)
+}
+
+type MockHistorianClientList struct {
+	mock.Mock
+}
+
+func (m *MockHistorianClientList) Do(opts ...googleapi.CallOption) (*gmail.ListHistoryResponse, error) {
+	args := m.Called(opts)
+	return args.Get(0).(*gmail.ListHistoryResponse), args.Error(1)
+}
+
+func TestSync(t *testing.T) {
+	testcases := []struct {
+		name            string
+		opts            google.HistoryListOptions
+		expectedOpts    google.HistoryListOptions
+		historyResponse *gmail.ListHistoryResponse
+		errList         error
+		expectedErr     error
+		assertErr       func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+		expectedChanges *models.MailboxChanges
+	}{
+		{
+			name:         "success - history records are grouped by change type.",
+			opts:         google.HistoryListOptions{StartHistoryID: 100, PageToken: "page-2"},
+			expectedOpts: google.HistoryListOptions{StartHistoryID: 100, PageToken: "page-2", MaxResults: historyLimit},
+			historyResponse: &gmail.ListHistoryResponse{
+				HistoryId:     120,
+				NextPageToken: "page-3",
+				History: []*gmail.History{
+					{
+						Id: 101,
+						MessagesAdded: []*gmail.HistoryMessageAdded{
+							{Message: &gmail.Message{Id: "MESSAGE_1", ThreadId: "THREAD_1", LabelIds: []string{"INBOX", "UNREAD"}}},
+						},
+					},
+					{
+						Id: 102,
+						LabelsRemoved: []*gmail.HistoryLabelRemoved{
+							{Message: &gmail.Message{Id: "MESSAGE_1", ThreadId: "THREAD_1"}, LabelIds: []string{"UNREAD"}},
+						},
+						LabelsAdded: []*gmail.HistoryLabelAdded{
+							{Message: &gmail.Message{Id: "MESSAGE_2", ThreadId: "THREAD_2"}, LabelIds: []string{"STARRED"}},
+						},
+					},
+					{
+						Id: 103,
+						MessagesDeleted: []*gmail.HistoryMessageDeleted{
+							{Message: &gmail.Message{Id: "MESSAGE_3", ThreadId: "THREAD_3"}},
+						},
+					},
+				},
+			},
+			assertErr: assert.Nil,
+			expectedChanges: &models.MailboxChanges{
+				HistoryID:       120,
+				NextPageToken:   "page-3",
+				MessagesAdded:   []*models.MessageChange{{ID: "MESSAGE_1", ThreadID: "THREAD_1", LabelIDs: []string{"INBOX", "UNREAD"}}},
+				MessagesDeleted: []*models.MessageChange{{ID: "MESSAGE_3", ThreadID: "THREAD_3"}},
+				LabelsAdded:     []*models.MessageChange{{ID: "MESSAGE_2", ThreadID: "THREAD_2", LabelIDs: []string{"STARRED"}}},
+				LabelsRemoved:   []*models.MessageChange{{ID: "MESSAGE_1", ThreadID: "THREAD_1", LabelIDs: []string{"UNREAD"}}},
+			},
+		},
+		{
+			name:            "success - no changes since the history id.",
+			opts:            google.HistoryListOptions{StartHistoryID: 100},
+			expectedOpts:    google.HistoryListOptions{StartHistoryID: 100, MaxResults: historyLimit},
+			historyResponse: &gmail.ListHistoryResponse{HistoryId: 100},
+			assertErr:       assert.Nil,
+			expectedChanges: &models.MailboxChanges{
+				HistoryID:       100,
+				MessagesAdded:   []*models.MessageChange{},
+				MessagesDeleted: []*models.MessageChange{},
+				LabelsAdded:     []*models.MessageChange{},
+				LabelsRemoved:   []*models.MessageChange{},
+			},
+		},
+		{
+			name:            "failure - history id is too old.",
+			opts:            google.HistoryListOptions{StartHistoryID: 1},
+			expectedOpts:    google.HistoryListOptions{StartHistoryID: 1, MaxResults: historyLimit},
+			historyResponse: &gmail.ListHistoryResponse{},
+			errList:         &googleapi.Error{Code: http.StatusNotFound, Message: "Requested entity was not found."},
+			expectedErr:     models.ErrHistoryExpired{},
+			assertErr:       assert.NotNil,
+		},
+		{
+			name:            "failure - gmail history service responds an error.",
+			opts:            google.HistoryListOptions{StartHistoryID: 100},
+			expectedOpts:    google.HistoryListOptions{StartHistoryID: 100, MaxResults: historyLimit},
+			historyResponse: &gmail.ListHistoryResponse{},
+			errList:         errors.New("backend error"),
+			expectedErr:     errors.New("backend error"),
+			assertErr:       assert.NotNil,
+		},
+		{
+			name:        "failure - missing history id.",
+			expectedErr: models.ErrInvalidData{Field: "since_history_id"},
+			assertErr:   assert.NotNil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			logger := log.NewLogfmtLogger(os.Stdin)
+			mockGmailService := &MockGmailService{}
+			mailxSvc := &MockMailxService{}
+			historian := &MockHistorian{}
+			call := &MockHistorianClientList{}
+
+			call.On("Do", []googleapi.CallOption(nil)).Return(test.historyResponse, test.errList)
+			historian.On("List", mock.Anything, "1", test.expectedOpts).Return(call)
+			mockGmailService.On("GetHistoryService").Return(historian)
+			mailxSvc.On("GetGmailService", "1").Return(mockGmailService)
+
+			historySvc := New(logger, nil, mailxSvc)
+			changes, err := historySvc.Sync(context.Background(), "1", test.opts)
+			test.assertErr(t, err)
+			if test.expectedErr != nil {
+				assert.Equal(t, test.expectedErr, err)
+			}
+			assert.Equal(t, test.expectedChanges, changes)
+		})
+	}
+}
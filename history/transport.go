@@ -0,0 +1,67 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/middlewares"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+)
+
+func MakeHandler(historyService Service, logger log.Logger) http.Handler {
+	r := mux.NewRouter()
+
+	e := MakeEndpoints(historyService)
+	options := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(models.ErrorEncoder),
+	}
+	r.Methods(http.MethodGet).
+		Path("/sync").
+		Handler(kithttp.NewServer(
+			e.SyncEndpoint,
+			decodeSyncRequest,
+			encodeSyncResponse,
+			options...,
+		))
+
+	return r
+}
+
+func decodeSyncRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	userID, err := middlewares.UserIDFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+	historyID, err := strconv.ParseUint(query.Get("since_history_id"), 10, 64)
+	if err != nil || historyID == 0 {
+		return nil, models.ErrInvalidData{Field: "since_history_id"}
+	}
+
+	return syncRequest{
+		UserID: userID,
+		Options: google.HistoryListOptions{
+			StartHistoryID: historyID,
+			PageToken:      query.Get("page_token"),
+		},
+	}, nil
+}
+
+func encodeSyncResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	if e, ok := response.(errorer); ok && e.error() != nil {
+		return e.error()
+	}
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type errorer interface {
+	error() error
+}
@@ -0,0 +1,51 @@
+package history
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/middlewares"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeSyncRequest(t *testing.T) {
+	testcases := []struct {
+		name            string
+		url             string
+		expectedRequest interface{}
+		expectedErr     error
+	}{
+		{
+			name: "success - history id and page token are decoded.",
+			url:  "/sync?since_history_id=1234&page_token=abc",
+			expectedRequest: syncRequest{
+				UserID:  "1",
+				Options: google.HistoryListOptions{StartHistoryID: 1234, PageToken: "abc"},
+			},
+		},
+		{
+			name:        "failure - missing history id.",
+			url:         "/sync",
+			expectedErr: models.ErrInvalidData{Field: "since_history_id"},
+		},
+		{
+			name:        "failure - history id is not a number.",
+			url:         "/sync?since_history_id=-1",
+			expectedErr: models.ErrInvalidData{Field: "since_history_id"},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.url, nil)
+			req = req.WithContext(middlewares.WithPrincipal(req.Context(), &middlewares.Principal{UserID: "1"}))
+			request, err := decodeSyncRequest(context.Background(), req)
+			assert.Equal(t, test.expectedErr, err)
+			assert.Equal(t, test.expectedRequest, request)
+		})
+	}
+}
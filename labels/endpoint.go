@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/go-kit/kit/endpoint"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
 	"google.golang.org/api/gmail/v1"
 )
 
@@ -12,6 +13,7 @@ type Endpoints struct {
 	DeleteLabelEndpoint  endpoint.Endpoint
 	GetLabelByIdEndpoint endpoint.Endpoint
 	GetLabelsEndpoint    endpoint.Endpoint
+	PatchLabelEndpoint   endpoint.Endpoint
 	UpdateLabelEndpoint  endpoint.Endpoint
 }
 
@@ -21,6 +23,7 @@ func MakeEndpoints(s Service) Endpoints {
 		DeleteLabelEndpoint:  MakeDeleteLabelEndpoint(s),
 		GetLabelByIdEndpoint: MakeGetLabelByIdEndpoint(s),
 		GetLabelsEndpoint:    MakeGetLabelsEndpoint(s),
+		PatchLabelEndpoint:   MakePatchLabelEndpoint(s),
 		UpdateLabelEndpoint:  MakeUpdateLabelEndpoint(s),
 	}
 }
@@ -33,26 +36,47 @@ func MakeGetUserProfileEndpoint(s Service) endpoint.Endpoint {
 
 func MakeCreateLabelEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
-		return nil, nil
+		req := request.(createLabelRequest)
+		label, err := s.CreateLabel(ctx, req.UserID, req.Label)
+		if err != nil {
+			return labelResponse{Err: err}, nil
+		}
+
+		return labelResponse{
+			Label: label,
+		}, nil
 	}
 }
 
 func MakeDeleteLabelEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
-		return nil, nil
+		req := request.(labelByIdRequest)
+		if err := s.DeleteLabel(ctx, req.UserID, req.LabelID); err != nil {
+			return deleteLabelResponse{Err: err}, nil
+		}
+
+		return deleteLabelResponse{}, nil
 	}
 }
 
 func MakeGetLabelByIdEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
-		return nil, nil
+		req := request.(labelByIdRequest)
+		label, err := s.GetLabelById(ctx, req.UserID, req.LabelID)
+		if err != nil {
+			return labelResponse{Err: err}, nil
+		}
+
+		return labelResponse{
+			Label: label,
+		}, nil
 	}
 }
 
 func MakeGetLabelsEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(getLabelsRequest)
-		labels, err := s.GetLabels(req.UserID)
+		labels, err := s.GetLabels(ctx, req.UserID)
 		if err != nil {
 			return getLabelsResponse{Err: err}, nil
 		}
@@ -63,9 +87,31 @@ func MakeGetLabelsEndpoint(s Service) endpoint.Endpoint {
 	}
 }
 
+func MakePatchLabelEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(updateLabelRequest)
+		label, err := s.PatchLabel(ctx, req.UserID, req.LabelID, req.Label)
+		if err != nil {
+			return labelResponse{Err: err}, nil
+		}
+
+		return labelResponse{
+			Label: label,
+		}, nil
+	}
+}
+
 func MakeUpdateLabelEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
-		return nil, nil
+		req := request.(updateLabelRequest)
+		label, err := s.UpdateLabel(ctx, req.UserID, req.LabelID, req.Label)
+		if err != nil {
+			return labelResponse{Err: err}, nil
+		}
+
+		return labelResponse{
+			Label: label,
+		}, nil
 	}
 }
 
@@ -81,3 +127,36 @@ type getLabelsResponse struct {
 func (g getLabelsResponse) error() error {
 	return g.Err
 }
+
+type createLabelRequest struct {
+	UserID string
+	Label  *models.Label
+}
+
+type labelByIdRequest struct {
+	UserID  string
+	LabelID string
+}
+
+type updateLabelRequest struct {
+	UserID  string
+	LabelID string
+	Label   *models.Label
+}
+
+type labelResponse struct {
+	Label *gmail.Label `json:"label"`
+	Err   error        `json:"error,omitempty"`
+}
+
+func (l labelResponse) error() error {
+	return l.Err
+}
+
+type deleteLabelResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (d deleteLabelResponse) error() error {
+	return d.Err
+}
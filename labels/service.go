@@ -8,16 +8,24 @@ import (
 	"github.com/go-kit/log"
 	"github.com/orlandorode97/mailx-google-service"
 	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
 	"github.com/orlandorode97/mailx-google-service/pkg/repos"
 	"google.golang.org/api/gmail/v1"
 )
 
 type Service interface {
-	CreateLabel()
-	DeleteLabel()
-	GetLabelById()
-	GetLabels(string) ([]*gmail.Label, error)
-	UpdateLabel()
+	// CreateLabel creates a new label in the user mailbox.
+	CreateLabel(context.Context, string, *models.Label) (*gmail.Label, error)
+	// DeleteLabel removes a label and its association with any messages and threads.
+	DeleteLabel(context.Context, string, string) error
+	// GetLabelById returns a label by its ID.
+	GetLabelById(context.Context, string, string) (*gmail.Label, error)
+	// GetLabels returns all the labels in the user mailbox.
+	GetLabels(context.Context, string) ([]*gmail.Label, error)
+	// PatchLabel updates only the non empty fields of a label.
+	PatchLabel(context.Context, string, string, *models.Label) (*gmail.Label, error)
+	// UpdateLabel replaces a label with the given fields.
+	UpdateLabel(context.Context, string, string, *models.Label) (*gmail.Label, error)
 }
 
 type service struct {
@@ -43,36 +51,107 @@ func (s *service) getLabelService(userID string) google.Labeler {
 }
 
 func (s *service) recreateLabelService(ctx context.Context, userID string) (google.Labeler, error) {
-	_, err := s.mailxSvc.RecreateGmailService(ctx, userID)
+	svc, err := s.mailxSvc.RecreateGmailService(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
-	return s.getLabelService(userID), nil
+	return svc.GetLabelsService(), nil
 }
 
-func (s *service) CreateLabel() {
+// labelService returns the labels service attached to the user, recreating the gmail service when it is missing.
+func (s *service) labelService(ctx context.Context, userID string) (google.Labeler, error) {
+	if svc := s.getLabelService(userID); svc != nil {
+		return svc, nil
+	}
+	return s.recreateLabelService(ctx, userID)
+}
+
+func (s *service) CreateLabel(ctx context.Context, userID string, label *models.Label) (*gmail.Label, error) {
+	if label == nil || label.Name == "" {
+		return nil, models.ErrInvalidData{Field: "name"}
+	}
+
+	if err := label.Validate(); err != nil {
+		return nil, err
+	}
 
+	svc, err := s.labelService(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := svc.Create(ctx, userID, label.GmailLabel()).Do()
+	if err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error creating label for user=%s", userID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return nil, err
+	}
+
+	s.logger.Log(
+		"message", fmt.Sprintf("create label=%s for user=%s", created.Id, userID),
+		"severity", "INFO",
+	)
+
+	return created, nil
 }
 
-func (s *service) DeleteLabel() {
+func (s *service) DeleteLabel(ctx context.Context, userID string, labelID string) error {
+	svc, err := s.labelService(ctx, userID)
+	if err != nil {
+		return err
+	}
 
+	if err := svc.Delete(ctx, userID, labelID).Do(); err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error deleting label=%s for user=%s", labelID, userID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return err
+	}
+
+	s.logger.Log(
+		"message", fmt.Sprintf("delete label=%s for user=%s", labelID, userID),
+		"severity", "INFO",
+	)
+
+	return nil
 }
 
-func (s *service) GetLabelById() {
+func (s *service) GetLabelById(ctx context.Context, userID string, labelID string) (*gmail.Label, error) {
+	svc, err := s.labelService(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	label, err := svc.Get(ctx, userID, labelID).Do()
+	if err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error getting label=%s for user=%s", labelID, userID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return nil, err
+	}
 
+	s.logger.Log(
+		"message", fmt.Sprintf("get label=%s for user=%s", labelID, userID),
+		"severity", "INFO",
+	)
+
+	return label, nil
 }
 
-func (s *service) GetLabels(userID string) ([]*gmail.Label, error) {
-	var svc google.Labeler
-	var err error
-	if svc = s.getLabelService(userID); svc == nil {
-		svc, err = s.recreateLabelService(context.Background(), userID)
-		if err != nil {
-			return nil, err
-		}
+func (s *service) GetLabels(ctx context.Context, userID string) ([]*gmail.Label, error) {
+	svc, err := s.labelService(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
 
-	labelListCall := svc.List(userID)
+	labelListCall := svc.List(ctx, userID)
 	labels, err := labelListCall.Do()
 
 	if err != nil {
@@ -92,5 +171,69 @@ func (s *service) GetLabels(userID string) ([]*gmail.Label, error) {
 	return labels.Labels, nil
 }
 
-func (s *service) UpdateLabel() {
+func (s *service) PatchLabel(ctx context.Context, userID string, labelID string, label *models.Label) (*gmail.Label, error) {
+	if label == nil {
+		return nil, models.ErrInvalidData{Field: "label"}
+	}
+
+	if err := label.Validate(); err != nil {
+		return nil, err
+	}
+
+	svc, err := s.labelService(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	patched, err := svc.Patch(ctx, userID, labelID, label.GmailLabel()).Do()
+	if err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error patching label=%s for user=%s", labelID, userID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return nil, err
+	}
+
+	s.logger.Log(
+		"message", fmt.Sprintf("patch label=%s for user=%s", labelID, userID),
+		"severity", "INFO",
+	)
+
+	return patched, nil
+}
+
+func (s *service) UpdateLabel(ctx context.Context, userID string, labelID string, label *models.Label) (*gmail.Label, error) {
+	if label == nil || label.Name == "" {
+		return nil, models.ErrInvalidData{Field: "name"}
+	}
+
+	if err := label.Validate(); err != nil {
+		return nil, err
+	}
+
+	svc, err := s.labelService(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	gmailLabel := label.GmailLabel()
+	gmailLabel.Id = labelID
+
+	updated, err := svc.Update(ctx, userID, labelID, gmailLabel).Do()
+	if err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error updating label=%s for user=%s", labelID, userID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return nil, err
+	}
+
+	s.logger.Log(
+		"message", fmt.Sprintf("update label=%s for user=%s", labelID, userID),
+		"severity", "INFO",
+	)
+
+	return updated, nil
 }
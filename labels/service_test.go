@@ -7,7 +7,9 @@ import (
 	"testing"
 
 	"github.com/go-kit/log"
+	"github.com/orlandorode97/mailx-google-service"
 	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"golang.org/x/oauth2"
@@ -28,32 +30,52 @@ func (m MockGmailService) GetMessagesService() google.Messenger {
 	return args.Get(0).(google.Messenger)
 }
 
+func (m MockGmailService) GetAttachmentsService() google.Attacher {
+	args := m.Called()
+	return args.Get(0).(google.Attacher)
+}
+
+func (m MockGmailService) GetThreadsService() google.Threader {
+	args := m.Called()
+	return args.Get(0).(google.Threader)
+}
+
+func (m MockGmailService) GetDraftsService() google.Drafter {
+	args := m.Called()
+	return args.Get(0).(google.Drafter)
+}
+
+func (m MockGmailService) GetHistoryService() google.Historian {
+	args := m.Called()
+	return args.Get(0).(google.Historian)
+}
+
 type MockLabeler struct {
 	mock.Mock
 }
 
-func (m MockLabeler) Create(ID string, label *gmail.Label) google.LabelerClient {
-	args := m.Called(ID, label)
+func (m MockLabeler) Create(ctx context.Context, ID string, label *gmail.Label) google.LabelerClient {
+	args := m.Called(ctx, ID, label)
 	return args.Get(0).(google.LabelerClient)
 }
-func (m MockLabeler) Delete(userID string, labelID string) google.LabelerClientDelete {
-	args := m.Called(userID, labelID)
+func (m MockLabeler) Delete(ctx context.Context, userID string, labelID string) google.LabelerClientDelete {
+	args := m.Called(ctx, userID, labelID)
 	return args.Get(0).(google.LabelerClientDelete)
 }
-func (m MockLabeler) Get(userID string, labelID string) google.LabelerClient {
-	args := m.Called(userID, labelID)
+func (m MockLabeler) Get(ctx context.Context, userID string, labelID string) google.LabelerClient {
+	args := m.Called(ctx, userID, labelID)
 	return args.Get(0).(google.LabelerClient)
 }
-func (m MockLabeler) List(userID string) google.LabelerClientList {
-	args := m.Called(userID)
+func (m MockLabeler) List(ctx context.Context, userID string) google.LabelerClientList {
+	args := m.Called(ctx, userID)
 	return args.Get(0).(google.LabelerClientList)
 }
-func (m MockLabeler) Patch(userID string, labelID string, label *gmail.Label) google.LabelerClient {
-	args := m.Called(userID, labelID, label)
+func (m MockLabeler) Patch(ctx context.Context, userID string, labelID string, label *gmail.Label) google.LabelerClient {
+	args := m.Called(ctx, userID, labelID, label)
 	return args.Get(0).(google.LabelerClient)
 }
-func (m MockLabeler) Update(userID string, labelID string, label *gmail.Label) google.LabelerClient {
-	args := m.Called(userID, labelID, label)
+func (m MockLabeler) Update(ctx context.Context, userID string, labelID string, label *gmail.Label) google.LabelerClient {
+	args := m.Called(ctx, userID, labelID, label)
 	return args.Get(0).(google.LabelerClient)
 }
 
@@ -66,8 +88,8 @@ func (m MockMailxService) GetGmailService(userID string) google.Service {
 	return args.Get(0).(google.Service)
 }
 
-func (m MockMailxService) CreateGmailService(token *oauth2.Token) (google.Service, error) {
-	args := m.Called(token)
+func (m MockMailxService) CreateGmailService(ctx context.Context, userID string, token *oauth2.Token) (google.Service, error) {
+	args := m.Called(ctx, userID, token)
 	return args.Get(0).(google.Service), args.Error(1)
 }
 
@@ -76,6 +98,15 @@ func (m MockMailxService) AddGmailServiceByID(ID string, gmailSvc google.Service
 	return args.Get(0).(google.Service)
 }
 
+func (m MockMailxService) Evict(ID string) {
+	m.Called(ID)
+}
+
+func (m MockMailxService) CacheStats() mailx.CacheStats {
+	args := m.Called()
+	return args.Get(0).(mailx.CacheStats)
+}
+
 func (m MockMailxService) RecreateGmailService(ctx context.Context, ID string) (google.Service, error) {
 	args := m.Called(ctx, ID)
 	return args.Get(0).(google.Service), args.Error(1)
@@ -154,7 +185,7 @@ func TestGetLabels(t *testing.T) {
 				mockCall := MockLabelerClientList{}
 
 				mockCall.On("Do", []googleapi.CallOption(nil)).Return(test.labelResponse, test.errLabels)
-				mockLabeler.On("List", test.userID).Return(mockCall)
+				mockLabeler.On("List", mock.Anything, test.userID).Return(mockCall)
 				mockGmailService.On("GetLabelsService").Return(mockLabeler)
 
 				if test.isGmailSvcNil {
@@ -167,10 +198,404 @@ func TestGetLabels(t *testing.T) {
 				}
 
 				labelsSvc := New(logger, nil, mailxSvc)
-				_, err := labelsSvc.GetLabels(test.userID)
+				_, err := labelsSvc.GetLabels(test.ctx, test.userID)
 				test.assertErr(t, err)
 			})
 		})
 	}
 
 }
+
+type MockLabelerClient struct {
+	mock.Mock
+}
+
+func (m *MockLabelerClient) Do(opts ...googleapi.CallOption) (*gmail.Label, error) {
+	args := m.Called(opts)
+	return args.Get(0).(*gmail.Label), args.Error(1)
+}
+
+type MockLabelerClientDelete struct {
+	mock.Mock
+}
+
+func (m *MockLabelerClientDelete) Do(opts ...googleapi.CallOption) error {
+	args := m.Called(opts)
+	return args.Error(0)
+}
+
+func TestCreateLabel(t *testing.T) {
+	testcases := []struct {
+		name        string
+		ctx         context.Context
+		userID      string
+		label       *models.Label
+		response    *gmail.Label
+		errCreate   error
+		assertErr   func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+		assertLabel func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+	}{
+		{
+			name:   "success - label created by the gmail api.",
+			ctx:    context.Background(),
+			userID: "1",
+			label: &models.Label{
+				Name:                  "Label 1",
+				LabelListVisibility:   "labelShow",
+				MessageListVisibility: "show",
+				Color: &models.LabelColor{
+					BackgroundColor: "#000000",
+					TextColor:       "#ffffff",
+				},
+			},
+			response: &gmail.Label{
+				Id:   "LABEL_1",
+				Name: "Label 1",
+			},
+			assertErr:   assert.Nil,
+			assertLabel: assert.NotNil,
+		},
+		{
+			name:        "failure - label name is missing.",
+			ctx:         context.Background(),
+			userID:      "1",
+			label:       &models.Label{},
+			response:    &gmail.Label{},
+			assertErr:   assert.NotNil,
+			assertLabel: assert.Nil,
+		},
+		{
+			name:   "failure - label visibility is invalid.",
+			ctx:    context.Background(),
+			userID: "1",
+			label: &models.Label{
+				Name:                "Label 1",
+				LabelListVisibility: "visible",
+			},
+			response:    &gmail.Label{},
+			assertErr:   assert.NotNil,
+			assertLabel: assert.Nil,
+		},
+		{
+			name:   "failure - gmail labels service responds an error.",
+			ctx:    context.Background(),
+			userID: "1",
+			label: &models.Label{
+				Name: "Label 1",
+			},
+			response:    &gmail.Label{},
+			errCreate:   errors.New("label name exists or conflicts"),
+			assertErr:   assert.NotNil,
+			assertLabel: assert.Nil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			logger := log.NewLogfmtLogger(os.Stdin)
+			mockGmailService := MockGmailService{}
+			mailxSvc := MockMailxService{}
+			mockLabeler := MockLabeler{}
+			mockCall := &MockLabelerClient{}
+
+			mockCall.On("Do", []googleapi.CallOption(nil)).Return(test.response, test.errCreate)
+			mockLabeler.On("Create", mock.Anything, test.userID, test.label.GmailLabel()).Return(mockCall)
+			mockGmailService.On("GetLabelsService").Return(mockLabeler)
+			mailxSvc.On("GetGmailService", test.userID).Return(mockGmailService)
+
+			labelsSvc := New(logger, nil, mailxSvc)
+			label, err := labelsSvc.CreateLabel(test.ctx, test.userID, test.label)
+			test.assertErr(t, err)
+			test.assertLabel(t, label)
+		})
+	}
+}
+
+func TestGetLabelById(t *testing.T) {
+	testcases := []struct {
+		name                string
+		ctx                 context.Context
+		userID              string
+		labelID             string
+		isGmailSvcNil       bool
+		errGmailSvcRecreate error
+		response            *gmail.Label
+		errGet              error
+		assertErr           func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+		assertLabel         func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+	}{
+		{
+			name:    "success - label returned by the gmail api.",
+			ctx:     context.Background(),
+			userID:  "1",
+			labelID: "LABEL_1",
+			response: &gmail.Label{
+				Id:   "LABEL_1",
+				Name: "Label 1",
+			},
+			assertErr:   assert.Nil,
+			assertLabel: assert.NotNil,
+		},
+		{
+			name:          "success - gmail service is recreated before getting the label.",
+			ctx:           context.Background(),
+			userID:        "1",
+			labelID:       "LABEL_1",
+			isGmailSvcNil: true,
+			response: &gmail.Label{
+				Id:   "LABEL_1",
+				Name: "Label 1",
+			},
+			assertErr:   assert.Nil,
+			assertLabel: assert.NotNil,
+		},
+		{
+			name:                "failure - cannot recreate gmail service.",
+			ctx:                 context.Background(),
+			userID:              "1",
+			labelID:             "LABEL_1",
+			isGmailSvcNil:       true,
+			errGmailSvcRecreate: errors.New("Cannot recreate gmail service"),
+			response:            &gmail.Label{},
+			assertErr:           assert.NotNil,
+			assertLabel:         assert.Nil,
+		},
+		{
+			name:        "failure - label does not exist.",
+			ctx:         context.Background(),
+			userID:      "1",
+			labelID:     "LABEL_1",
+			response:    &gmail.Label{},
+			errGet:      errors.New("requested entity was not found"),
+			assertErr:   assert.NotNil,
+			assertLabel: assert.Nil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			logger := log.NewLogfmtLogger(os.Stdin)
+			mockGmailService := MockGmailService{}
+			mailxSvc := MockMailxService{}
+			mockLabeler := MockLabeler{}
+			mockCall := &MockLabelerClient{}
+
+			mockCall.On("Do", []googleapi.CallOption(nil)).Return(test.response, test.errGet)
+			mockLabeler.On("Get", mock.Anything, test.userID, test.labelID).Return(mockCall)
+			mockGmailService.On("GetLabelsService").Return(mockLabeler)
+
+			if test.isGmailSvcNil {
+				mailxSvc.On("GetGmailService", test.userID).Return((*MockGmailService)(nil))
+				mailxSvc.On("RecreateGmailService", test.ctx, test.userID).Return(mockGmailService, test.errGmailSvcRecreate)
+			}
+
+			if !test.isGmailSvcNil {
+				mailxSvc.On("GetGmailService", test.userID).Return(mockGmailService)
+			}
+
+			labelsSvc := New(logger, nil, mailxSvc)
+			label, err := labelsSvc.GetLabelById(test.ctx, test.userID, test.labelID)
+			test.assertErr(t, err)
+			test.assertLabel(t, label)
+		})
+	}
+}
+
+func TestUpdateLabel(t *testing.T) {
+	testcases := []struct {
+		name        string
+		ctx         context.Context
+		userID      string
+		labelID     string
+		label       *models.Label
+		response    *gmail.Label
+		errUpdate   error
+		assertErr   func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+		assertLabel func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+	}{
+		{
+			name:    "success - label updated by the gmail api.",
+			ctx:     context.Background(),
+			userID:  "1",
+			labelID: "LABEL_1",
+			label: &models.Label{
+				Name: "Label 2",
+			},
+			response: &gmail.Label{
+				Id:   "LABEL_1",
+				Name: "Label 2",
+			},
+			assertErr:   assert.Nil,
+			assertLabel: assert.NotNil,
+		},
+		{
+			name:        "failure - label name is missing.",
+			ctx:         context.Background(),
+			userID:      "1",
+			labelID:     "LABEL_1",
+			label:       &models.Label{},
+			response:    &gmail.Label{},
+			assertErr:   assert.NotNil,
+			assertLabel: assert.Nil,
+		},
+		{
+			name:    "failure - gmail labels service responds an error.",
+			ctx:     context.Background(),
+			userID:  "1",
+			labelID: "LABEL_1",
+			label: &models.Label{
+				Name: "Label 2",
+			},
+			response:    &gmail.Label{},
+			errUpdate:   errors.New("invalid label name"),
+			assertErr:   assert.NotNil,
+			assertLabel: assert.Nil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			logger := log.NewLogfmtLogger(os.Stdin)
+			mockGmailService := MockGmailService{}
+			mailxSvc := MockMailxService{}
+			mockLabeler := MockLabeler{}
+			mockCall := &MockLabelerClient{}
+
+			gmailLabel := test.label.GmailLabel()
+			gmailLabel.Id = test.labelID
+
+			mockCall.On("Do", []googleapi.CallOption(nil)).Return(test.response, test.errUpdate)
+			mockLabeler.On("Update", mock.Anything, test.userID, test.labelID, gmailLabel).Return(mockCall)
+			mockGmailService.On("GetLabelsService").Return(mockLabeler)
+			mailxSvc.On("GetGmailService", test.userID).Return(mockGmailService)
+
+			labelsSvc := New(logger, nil, mailxSvc)
+			label, err := labelsSvc.UpdateLabel(test.ctx, test.userID, test.labelID, test.label)
+			test.assertErr(t, err)
+			test.assertLabel(t, label)
+		})
+	}
+}
+
+func TestPatchLabel(t *testing.T) {
+	testcases := []struct {
+		name        string
+		ctx         context.Context
+		userID      string
+		labelID     string
+		label       *models.Label
+		response    *gmail.Label
+		errPatch    error
+		assertErr   func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+		assertLabel func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+	}{
+		{
+			name:    "success - label visibility patched by the gmail api.",
+			ctx:     context.Background(),
+			userID:  "1",
+			labelID: "LABEL_1",
+			label: &models.Label{
+				LabelListVisibility: "labelHide",
+			},
+			response: &gmail.Label{
+				Id:                  "LABEL_1",
+				LabelListVisibility: "labelHide",
+			},
+			assertErr:   assert.Nil,
+			assertLabel: assert.NotNil,
+		},
+		{
+			name:    "failure - label color is incomplete.",
+			ctx:     context.Background(),
+			userID:  "1",
+			labelID: "LABEL_1",
+			label: &models.Label{
+				Color: &models.LabelColor{
+					TextColor: "#ffffff",
+				},
+			},
+			response:    &gmail.Label{},
+			assertErr:   assert.NotNil,
+			assertLabel: assert.Nil,
+		},
+		{
+			name:    "failure - gmail labels service responds an error.",
+			ctx:     context.Background(),
+			userID:  "1",
+			labelID: "LABEL_1",
+			label: &models.Label{
+				MessageListVisibility: "hide",
+			},
+			response:    &gmail.Label{},
+			errPatch:    errors.New("requested entity was not found"),
+			assertErr:   assert.NotNil,
+			assertLabel: assert.Nil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			logger := log.NewLogfmtLogger(os.Stdin)
+			mockGmailService := MockGmailService{}
+			mailxSvc := MockMailxService{}
+			mockLabeler := MockLabeler{}
+			mockCall := &MockLabelerClient{}
+
+			mockCall.On("Do", []googleapi.CallOption(nil)).Return(test.response, test.errPatch)
+			mockLabeler.On("Patch", mock.Anything, test.userID, test.labelID, test.label.GmailLabel()).Return(mockCall)
+			mockGmailService.On("GetLabelsService").Return(mockLabeler)
+			mailxSvc.On("GetGmailService", test.userID).Return(mockGmailService)
+
+			labelsSvc := New(logger, nil, mailxSvc)
+			label, err := labelsSvc.PatchLabel(test.ctx, test.userID, test.labelID, test.label)
+			test.assertErr(t, err)
+			test.assertLabel(t, label)
+		})
+	}
+}
+
+func TestDeleteLabel(t *testing.T) {
+	testcases := []struct {
+		name      string
+		ctx       context.Context
+		userID    string
+		labelID   string
+		errDelete error
+		assertErr func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+	}{
+		{
+			name:      "success - label deleted by the gmail api.",
+			ctx:       context.Background(),
+			userID:    "1",
+			labelID:   "LABEL_1",
+			assertErr: assert.Nil,
+		},
+		{
+			name:      "failure - gmail labels service responds an error.",
+			ctx:       context.Background(),
+			userID:    "1",
+			labelID:   "INBOX",
+			errDelete: errors.New("invalid delete request"),
+			assertErr: assert.NotNil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			logger := log.NewLogfmtLogger(os.Stdin)
+			mockGmailService := MockGmailService{}
+			mailxSvc := MockMailxService{}
+			mockLabeler := MockLabeler{}
+			mockCall := &MockLabelerClientDelete{}
+
+			mockCall.On("Do", []googleapi.CallOption(nil)).Return(test.errDelete)
+			mockLabeler.On("Delete", mock.Anything, test.userID, test.labelID).Return(mockCall)
+			mockGmailService.On("GetLabelsService").Return(mockLabeler)
+			mailxSvc.On("GetGmailService", test.userID).Return(mockGmailService)
+
+			labelsSvc := New(logger, nil, mailxSvc)
+			err := labelsSvc.DeleteLabel(test.ctx, test.userID, test.labelID)
+			test.assertErr(t, err)
+		})
+	}
+}
@@ -24,15 +24,7 @@ func MakeHandler(labelService Service, logger log.Logger) http.Handler {
 		Path("/labels/").
 		Handler(kithttp.NewServer(
 			e.CreateLabelEndpoint,
-			decodeLabelsRequest,
-			encodeLabelsResponse,
-			options...,
-		))
-	r.Methods(http.MethodDelete).
-		Path("/labels/").
-		Handler(kithttp.NewServer(
-			e.DeleteLabelEndpoint,
-			decodeLabelsRequest,
+			decodeCreateLabelRequest,
 			encodeLabelsResponse,
 			options...,
 		))
@@ -47,41 +39,119 @@ func MakeHandler(labelService Service, logger log.Logger) http.Handler {
 	r.Methods(http.MethodGet).
 		Path("/labels/{id:[0-9a-zA-Z\\W]+|}").
 		Handler(kithttp.NewServer(
-			e.GetLabelsEndpoint,
-			decodeLabelsRequest,
+			e.GetLabelByIdEndpoint,
+			decodeLabelByIdRequest,
 			encodeLabelsResponse,
 			options...,
 		))
 	r.Methods(http.MethodPut).
-		Path("/labels/").
+		Path("/labels/{id:[0-9a-zA-Z\\W]+|}").
 		Handler(kithttp.NewServer(
 			e.UpdateLabelEndpoint,
-			decodeLabelsRequest,
+			decodeUpdateLabelRequest,
 			encodeLabelsResponse,
 			options...,
 		))
-	return middlewares.Authentication(r)
+	r.Methods(http.MethodPatch).
+		Path("/labels/{id:[0-9a-zA-Z\\W]+|}").
+		Handler(kithttp.NewServer(
+			e.PatchLabelEndpoint,
+			decodeUpdateLabelRequest,
+			encodeLabelsResponse,
+			options...,
+		))
+	r.Methods(http.MethodDelete).
+		Path("/labels/{id:[0-9a-zA-Z\\W]+|}").
+		Handler(kithttp.NewServer(
+			e.DeleteLabelEndpoint,
+			decodeLabelByIdRequest,
+			encodeDeleteLabelResponse,
+			options...,
+		))
+	return r
 }
 
 func decodeLabelsRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	if err, ok := r.Context().Value(middlewares.InvalidAuthKey).(error); ok && err != nil {
+	userID, err := middlewares.UserIDFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return getLabelsRequest{
+		UserID: userID,
+	}, nil
+}
+
+func decodeCreateLabelRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	userID, err := middlewares.UserIDFromRequest(r)
+	if err != nil {
 		return nil, err
 	}
 
-	userID, ok := r.Context().Value(middlewares.UserIDKey).(string)
-	if !ok {
-		return nil, models.ErrInvalidData{Field: "user_id"}
+	var label models.Label
+	if err := json.NewDecoder(r.Body).Decode(&label); err != nil {
+		return nil, models.ErrInvalidData{Field: "label"}
 	}
 
-	return getLabelsRequest{
+	return createLabelRequest{
 		UserID: userID,
+		Label:  &label,
+	}, nil
+}
+
+func decodeLabelByIdRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	userID, err := middlewares.UserIDFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	labelID := mux.Vars(r)["id"]
+	if labelID == "" {
+		return nil, models.ErrInvalidData{Field: "label_id"}
+	}
+
+	return labelByIdRequest{
+		UserID:  userID,
+		LabelID: labelID,
+	}, nil
+}
+
+func decodeUpdateLabelRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	request, err := decodeLabelByIdRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	req := request.(labelByIdRequest)
+
+	var label models.Label
+	if err := json.NewDecoder(r.Body).Decode(&label); err != nil {
+		return nil, models.ErrInvalidData{Field: "label"}
+	}
+
+	return updateLabelRequest{
+		UserID:  req.UserID,
+		LabelID: req.LabelID,
+		Label:   &label,
 	}, nil
 }
 
 func encodeLabelsResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if e, ok := response.(errorer); ok && e.error() != nil {
+		return e.error()
+	}
+
 	return json.NewEncoder(w).Encode(response)
 }
 
+func encodeDeleteLabelResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	if e, ok := response.(errorer); ok && e.error() != nil {
+		return e.error()
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
 type errorer interface {
 	error() error
 }
@@ -1 +1,75 @@
 package labels
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/orlandorode97/mailx-google-service/pkg/middlewares"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeCreateLabelRequest(t *testing.T) {
+	testcases := []struct {
+		name      string
+		body      string
+		userID    string
+		assertErr func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+	}{
+		{
+			name:      "success - label body is decoded.",
+			body:      `{"name": "Label 1", "label_list_visibility": "labelShow", "color": {"background_color": "#000000", "text_color": "#ffffff"}}`,
+			userID:    "1",
+			assertErr: assert.Nil,
+		},
+		{
+			name:      "failure - label body is malformed.",
+			body:      `{"name": `,
+			userID:    "1",
+			assertErr: assert.NotNil,
+		},
+		{
+			name:      "failure - user id is missing.",
+			body:      `{"name": "Label 1"}`,
+			assertErr: assert.NotNil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/labels/", strings.NewReader(test.body))
+			if test.userID != "" {
+				req = req.WithContext(middlewares.WithPrincipal(req.Context(), &middlewares.Principal{UserID: test.userID}))
+			}
+			request, err := decodeCreateLabelRequest(context.Background(), req)
+			test.assertErr(t, err)
+			if err == nil {
+				r := request.(createLabelRequest)
+				assert.Equal(t, "Label 1", r.Label.Name)
+				assert.Equal(t, "#ffffff", r.Label.Color.TextColor)
+			}
+		})
+	}
+}
+
+func TestDecodeLabelByIdRequest(t *testing.T) {
+	t.Run("success - label id is read from the path.", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/labels/Label_1", nil)
+		req = mux.SetURLVars(req, map[string]string{"id": "Label_1"})
+		req = req.WithContext(middlewares.WithPrincipal(req.Context(), &middlewares.Principal{UserID: "1"}))
+		request, err := decodeLabelByIdRequest(context.Background(), req)
+		assert.Nil(t, err)
+		assert.Equal(t, labelByIdRequest{UserID: "1", LabelID: "Label_1"}, request)
+	})
+
+	t.Run("failure - label id is missing.", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/labels/", nil)
+		req = req.WithContext(middlewares.WithPrincipal(req.Context(), &middlewares.Principal{UserID: "1"}))
+		_, err := decodeLabelByIdRequest(context.Background(), req)
+		assert.Equal(t, models.ErrInvalidData{Field: "label_id"}, err)
+	})
+}
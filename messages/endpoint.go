@@ -4,32 +4,50 @@ import (
 	"context"
 
 	"github.com/go-kit/kit/endpoint"
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
 	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"google.golang.org/api/gmail/v1"
 )
 
 type Endpoints struct {
-	GetMessagesEndpoint    endpoint.Endpoint
-	GetMessageByIDEndpoint endpoint.Endpoint
+	GetMessagesEndpoint     endpoint.Endpoint
+	GetMessageByIDEndpoint  endpoint.Endpoint
+	SendMessageEndpoint     endpoint.Endpoint
+	ReplyMessageEndpoint    endpoint.Endpoint
+	ReplyAllMessageEndpoint endpoint.Endpoint
+	ForwardMessageEndpoint  endpoint.Endpoint
+	GetAttachmentEndpoint   endpoint.Endpoint
 }
 
 func MakeEndpoints(s Service) Endpoints {
 	return Endpoints{
-		GetMessagesEndpoint:    MakeGetMessages(s),
-		GetMessageByIDEndpoint: MakeGetMessageByID(s),
+		GetMessagesEndpoint:     MakeGetMessages(s),
+		GetMessageByIDEndpoint:  MakeGetMessageByID(s),
+		SendMessageEndpoint:     MakeSendMessage(s),
+		ReplyMessageEndpoint:    MakeRespondMessage(s.ReplyMessage),
+		ReplyAllMessageEndpoint: MakeRespondMessage(s.ReplyAllMessage),
+		ForwardMessageEndpoint:  MakeRespondMessage(s.ForwardMessage),
+		GetAttachmentEndpoint:   MakeGetAttachment(s),
 	}
 }
 
 func MakeGetMessages(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(getMessagesRequest)
-		messages, err := s.GetMessages(ctx, req.UserID)
+		page, err := s.GetMessages(ctx, req.UserID, req.Options)
 		if err != nil {
 			return getMessagesResponse{
 				Err: err,
 			}, nil
 		}
+		for _, message := range page.Messages {
+			req.Format.Apply(message)
+		}
 		return getMessagesResponse{
-			Messages: messages,
+			Messages:           page.Messages,
+			NextPageToken:      page.NextPageToken,
+			ResultSizeEstimate: page.ResultSizeEstimate,
+			Errors:             page.Errors,
 		}, nil
 	}
 }
@@ -44,26 +62,121 @@ func MakeGetMessageByID(s Service) endpoint.Endpoint {
 			}, nil
 		}
 		return getMessageByIDResponse{
+			Message: req.Format.Apply(message),
+		}, nil
+	}
+}
+
+func MakeSendMessage(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(sendMessageRequest)
+		message, err := s.SendMessage(ctx, req.UserID, req.Message)
+		if err != nil {
+			return sendMessageResponse{
+				Err: err,
+			}, nil
+		}
+		return sendMessageResponse{
 			Message: message,
 		}, nil
 	}
 }
 
+// MakeRespondMessage builds the reply, reply-all and forward endpoints since they share the same request and response.
+func MakeRespondMessage(respond func(context.Context, string, string, *models.OutgoingMessage) (*gmail.Message, error)) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(respondMessageRequest)
+		message, err := respond(ctx, req.UserID, req.MessageID, req.Message)
+		if err != nil {
+			return sendMessageResponse{
+				Err: err,
+			}, nil
+		}
+		return sendMessageResponse{
+			Message: message,
+		}, nil
+	}
+}
+
+func MakeGetAttachment(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(getAttachmentRequest)
+		attachment, err := s.GetAttachment(ctx, req.UserID, req.MessageID, req.AttachmentID)
+		if err != nil {
+			return getAttachmentResponse{
+				Err: err,
+			}, nil
+		}
+		return getAttachmentResponse{
+			Attachment: attachment,
+		}, nil
+	}
+}
+
 type getMessagesRequest struct {
-	UserID string
+	UserID  string
+	Options google.MessageListOptions
+	Format  models.MessageFormat
 }
 
 type getMessagesResponse struct {
-	Messages []*models.Message `json:"messages"`
-	Err      error             `json:"error,omitempty"`
+	Messages           []*models.Message     `json:"messages"`
+	NextPageToken      string                `json:"next_page_token,omitempty"`
+	ResultSizeEstimate int64                 `json:"result_size_estimate"`
+	Errors             []models.MessageError `json:"errors,omitempty"`
+	Err                error                 `json:"error,omitempty"`
+}
+
+func (g getMessagesResponse) error() error {
+	return g.Err
 }
 
 type getMessageByIDRequest struct {
 	UserID    string
 	MessageID string
+	Format    models.MessageFormat
 }
 
 type getMessageByIDResponse struct {
 	Message *models.Message `json:"message"`
 	Err     error           `json:"error,omitempty"`
 }
+
+func (g getMessageByIDResponse) error() error {
+	return g.Err
+}
+
+type sendMessageRequest struct {
+	UserID  string
+	Message *models.OutgoingMessage
+}
+
+type respondMessageRequest struct {
+	UserID    string
+	MessageID string
+	Message   *models.OutgoingMessage
+}
+
+type sendMessageResponse struct {
+	Message *gmail.Message `json:"message"`
+	Err     error          `json:"error,omitempty"`
+}
+
+func (s sendMessageResponse) error() error {
+	return s.Err
+}
+
+type getAttachmentRequest struct {
+	UserID       string
+	MessageID    string
+	AttachmentID string
+}
+
+type getAttachmentResponse struct {
+	Attachment *models.AttachmentContent
+	Err        error
+}
+
+func (g getAttachmentResponse) error() error {
+	return g.Err
+}
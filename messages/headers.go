@@ -0,0 +1,86 @@
+package messages
+
+import (
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/orlandorode97/mailx-google-service/pkg/extractor"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"google.golang.org/api/gmail/v1"
+)
+
+// headerValues indexes the message headers by their canonical name, keeping the first value.
+func headerValues(headers []*gmail.MessagePartHeader) map[string]string {
+	values := make(map[string]string, len(headers))
+	for _, header := range headers {
+		key := textproto.CanonicalMIMEHeaderKey(header.Name)
+		if _, ok := values[key]; !ok {
+			values[key] = header.Value
+		}
+	}
+	return values
+}
+
+// applyHeaders sets the parsed top level headers of the payload on the message.
+func applyHeaders(message *models.Message, payload *gmail.MessagePart) {
+	if payload == nil {
+		return
+	}
+
+	headers := headerValues(payload.Headers)
+	if from := parseAddresses(headers["From"]); len(from) > 0 {
+		message.From = &from[0]
+	}
+	message.To = parseAddresses(headers["To"])
+	message.Cc = parseAddresses(headers["Cc"])
+	message.Bcc = parseAddresses(headers["Bcc"])
+	message.ReplyTo = parseAddresses(headers["Reply-To"])
+	message.Subject = extractor.DecodeHeader(headers["Subject"])
+	message.Date = parseDate(headers["Date"])
+	message.MessageID = strings.TrimSpace(headers["Message-Id"])
+	message.InReplyTo = strings.TrimSpace(headers["In-Reply-To"])
+	message.ListUnsubscribe = parseListUnsubscribe(headers["List-Unsubscribe"])
+}
+
+func parseAddresses(value string) []models.Address {
+	if value == "" {
+		return nil
+	}
+
+	list, err := extractor.ParseAddressList(value)
+	if err != nil {
+		return nil
+	}
+
+	addresses := make([]models.Address, 0, len(list))
+	for _, address := range list {
+		addresses = append(addresses, models.Address{Name: address.Name, Email: address.Address})
+	}
+	return addresses
+}
+
+func parseDate(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+
+	date, err := mail.ParseDate(value)
+	if err != nil {
+		return nil
+	}
+	return &date
+}
+
+// parseListUnsubscribe returns the URIs of a List-Unsubscribe header, e.g. `<mailto:leave@example.com>, <https://example.com/leave>`.
+func parseListUnsubscribe(value string) []string {
+	var uris []string
+	for _, uri := range strings.Split(value, ",") {
+		uri = strings.Trim(strings.TrimSpace(uri), "<>")
+		if uri != "" {
+			uris = append(uris, uri)
+		}
+	}
+	return uris
+}
@@ -0,0 +1,60 @@
+package messages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestApplyHeaders(t *testing.T) {
+	t.Run("success - headers are parsed and decoded.", func(t *testing.T) {
+		payload := &gmail.MessagePart{
+			Headers: []*gmail.MessagePartHeader{
+				{Name: "From", Value: "=?windows-1252?q?=93Jos=E9=94?= <jose@example.com>"},
+				{Name: "to", Value: "Ana <ana@example.com>, luis@example.com"},
+				{Name: "Cc", Value: "=?iso-8859-1?q?Mar=EDa?= <maria@example.com>"},
+				{Name: "Reply-To", Value: "replies@example.com"},
+				{Name: "Subject", Value: "=?utf-8?b?wqFIb2xhIQ==?= =?utf-8?q?_caf=C3=A9?="},
+				{Name: "Date", Value: "Mon, 2 Jan 2006 15:04:05 -0700"},
+				{Name: "Message-ID", Value: " <abc@mail.example.com> "},
+				{Name: "In-Reply-To", Value: "<xyz@mail.example.com>"},
+				{Name: "List-Unsubscribe", Value: "<mailto:leave@example.com>, <https://example.com/leave>"},
+			},
+		}
+
+		message := &models.Message{}
+		applyHeaders(message, payload)
+
+		date := time.Date(2006, time.January, 2, 15, 4, 5, 0, time.FixedZone("-0700", -7*60*60))
+		assert.Equal(t, &models.Address{Name: "“José”", Email: "jose@example.com"}, message.From)
+		assert.Equal(t, []models.Address{{Name: "Ana", Email: "ana@example.com"}, {Email: "luis@example.com"}}, message.To)
+		assert.Equal(t, []models.Address{{Name: "María", Email: "maria@example.com"}}, message.Cc)
+		assert.Nil(t, message.Bcc)
+		assert.Equal(t, []models.Address{{Email: "replies@example.com"}}, message.ReplyTo)
+		assert.Equal(t, "¡Hola! café", message.Subject)
+		assert.True(t, date.Equal(*message.Date))
+		assert.Equal(t, "<abc@mail.example.com>", message.MessageID)
+		assert.Equal(t, "<xyz@mail.example.com>", message.InReplyTo)
+		assert.Equal(t, []string{"mailto:leave@example.com", "https://example.com/leave"}, message.ListUnsubscribe)
+	})
+
+	t.Run("success - invalid headers are left empty.", func(t *testing.T) {
+		payload := &gmail.MessagePart{
+			Headers: []*gmail.MessagePartHeader{
+				{Name: "From", Value: "not an address"},
+				{Name: "Subject", Value: "plain subject"},
+				{Name: "Date", Value: "yesterday"},
+			},
+		}
+
+		message := &models.Message{}
+		applyHeaders(message, payload)
+
+		assert.Nil(t, message.From)
+		assert.Nil(t, message.Date)
+		assert.Equal(t, "plain subject", message.Subject)
+	})
+}
@@ -0,0 +1,300 @@
+package messages
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/orlandorode97/mailx-google-service/pkg/extractor"
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"google.golang.org/api/gmail/v1"
+)
+
+type respondMode int
+
+const (
+	modeReply respondMode = iota
+	modeReplyAll
+	modeForward
+)
+
+const sentLabel = "SENT"
+
+// originalContent holds the decoded bodies and attachment parts of the message being answered.
+type originalContent struct {
+	text        string
+	html        string
+	attachments []*gmail.MessagePart
+}
+
+func (s *service) ReplyMessage(ctx context.Context, userID string, messageID string, msg *models.OutgoingMessage) (*gmail.Message, error) {
+	return s.respond(ctx, userID, messageID, msg, modeReply)
+}
+
+func (s *service) ReplyAllMessage(ctx context.Context, userID string, messageID string, msg *models.OutgoingMessage) (*gmail.Message, error) {
+	return s.respond(ctx, userID, messageID, msg, modeReplyAll)
+}
+
+func (s *service) ForwardMessage(ctx context.Context, userID string, messageID string, msg *models.OutgoingMessage) (*gmail.Message, error) {
+	return s.respond(ctx, userID, messageID, msg, modeForward)
+}
+
+// respond loads the original message and sends a new one in the same thread, quoting the original bodies.
+func (s *service) respond(ctx context.Context, userID string, messageID string, msg *models.OutgoingMessage, mode respondMode) (*gmail.Message, error) {
+	if msg == nil {
+		return nil, models.ErrInvalidData{Field: "message"}
+	}
+
+	gmailSvc, err := s.gmailService(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	original, err := gmailSvc.GetMessagesService().Get(ctx, userID, messageID).Do()
+	if err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error getting original message=%s for user=%s", messageID, userID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return nil, err
+	}
+
+	if original.Payload == nil {
+		return nil, models.ErrInvalidData{Field: "message_id"}
+	}
+
+	content, err := extractOriginalContent(original.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := headerValues(original.Payload.Headers)
+	out := *msg
+	out.ThreadID = original.ThreadId
+	out.InReplyTo = headers["Message-Id"]
+	out.References = strings.TrimSpace(headers["References"] + " " + headers["Message-Id"])
+
+	// the subject may be RFC 2047 encoded, it is decoded before checking its prefix.
+	subject := extractor.DecodeHeader(headers["Subject"])
+	switch mode {
+	case modeForward:
+		out.Subject = prefixSubject("Fwd:", subject, msg.Subject)
+		out.Text, out.HTML = forwardBodies(msg, headers, content)
+
+		attachments, err := s.downloadAttachments(ctx, gmailSvc.GetAttachmentsService(), userID, messageID, content.attachments)
+		if err != nil {
+			return nil, err
+		}
+		out.Attachments = append(attachments, msg.Attachments...)
+	default:
+		out.To, out.Cc = replyRecipients(original, headers, mode == modeReplyAll)
+		out.To = mergeAddresses(out.To, msg.To)
+		out.Cc = mergeAddresses(out.Cc, msg.Cc)
+		out.Subject = prefixSubject("Re:", subject, msg.Subject)
+		out.Text, out.HTML = replyBodies(msg, headers, content)
+	}
+
+	return s.SendMessage(ctx, userID, &out)
+}
+
+func (s *service) downloadAttachments(ctx context.Context, svc google.Attacher, userID string, messageID string, parts []*gmail.MessagePart) ([]models.Attachment, error) {
+	attachments := make([]models.Attachment, 0, len(parts))
+	for _, part := range parts {
+		data := part.Body.Data
+		if part.Body.AttachmentId != "" {
+			body, err := svc.Get(ctx, userID, messageID, part.Body.AttachmentId).Do()
+			if err != nil {
+				s.logger.Log(
+					"message", fmt.Sprintf("error getting attachment=%s of message=%s for user=%s", part.Body.AttachmentId, messageID, userID),
+					"error", err.Error(),
+					"severity", "ERROR",
+				)
+				return nil, err
+			}
+			data = body.Data
+		}
+
+		decoded, err := extractor.DecodeData(data)
+		if err != nil {
+			return nil, err
+		}
+
+		attachments = append(attachments, models.Attachment{
+			Filename: part.Filename,
+			MimeType: part.MimeType,
+			Data:     decoded,
+		})
+	}
+	return attachments, nil
+}
+
+// extractOriginalContent returns the decoded bodies and the attachment parts of the original message.
+func extractOriginalContent(payload *gmail.MessagePart) (*originalContent, error) {
+	body, err := extractor.Extract(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	content := &originalContent{
+		text: body.Text,
+		html: body.HTML,
+	}
+	_ = extractor.Walk(payload, func(part *gmail.MessagePart) error {
+		if extractor.IsAttachment(part) && part.Body != nil {
+			content.attachments = append(content.attachments, part)
+		}
+		return nil
+	})
+	return content, nil
+}
+
+// replyRecipients computes the recipients of a reply. Replies to messages sent by the user go to the original recipients.
+func replyRecipients(original *gmail.Message, headers map[string]string, all bool) ([]models.Address, []models.Address) {
+	sentByUser := false
+	for _, label := range original.LabelIds {
+		if label == sentLabel {
+			sentByUser = true
+			break
+		}
+	}
+
+	self := parseAddresses(headers["Delivered-To"])
+	var to, cc []models.Address
+	switch {
+	case sentByUser:
+		self = parseAddresses(headers["From"])
+		to = parseAddresses(headers["To"])
+	case headers["Reply-To"] != "":
+		to = parseAddresses(headers["Reply-To"])
+	default:
+		to = parseAddresses(headers["From"])
+	}
+
+	if !all {
+		return to, nil
+	}
+
+	if !sentByUser {
+		to = mergeAddresses(to, excludeAddresses(parseAddresses(headers["To"]), self))
+	}
+	cc = excludeAddresses(parseAddresses(headers["Cc"]), append(self, to...))
+	return to, cc
+}
+
+// mergeAddresses appends the extra addresses that are not already present in addresses.
+func mergeAddresses(addresses []models.Address, extra []models.Address) []models.Address {
+	return append(addresses, excludeAddresses(extra, addresses)...)
+}
+
+// excludeAddresses returns the addresses whose email is not present in excluded, without duplicates.
+func excludeAddresses(addresses []models.Address, excluded []models.Address) []models.Address {
+	seen := make(map[string]bool, len(excluded))
+	for _, address := range excluded {
+		seen[strings.ToLower(address.Email)] = true
+	}
+
+	result := make([]models.Address, 0, len(addresses))
+	for _, address := range addresses {
+		email := strings.ToLower(address.Email)
+		if seen[email] {
+			continue
+		}
+		seen[email] = true
+		result = append(result, address)
+	}
+	return result
+}
+
+// prefixSubject keeps the subject requested by the client or prefixes the original one, e.g. `Re: subject`.
+func prefixSubject(prefix string, original string, requested string) string {
+	if requested != "" {
+		return requested
+	}
+	if strings.HasPrefix(strings.ToLower(original), strings.ToLower(prefix)) {
+		return original
+	}
+	return strings.TrimSpace(prefix + " " + original)
+}
+
+func replyBodies(msg *models.OutgoingMessage, headers map[string]string, content *originalContent) (string, string) {
+	attribution := fmt.Sprintf("On %s, %s wrote:", extractor.DecodeHeader(headers["Date"]), extractor.DecodeHeader(headers["From"]))
+
+	originalText := content.text
+	if originalText == "" {
+		originalText = stripLines(content.html)
+	}
+	quoted := make([]string, 0)
+	for _, line := range strings.Split(strings.ReplaceAll(originalText, "\r\n", "\n"), "\n") {
+		quoted = append(quoted, "> "+line)
+	}
+	text := strings.Join([]string{msg.Text, "", attribution, strings.Join(quoted, "\n")}, "\n")
+
+	body := fmt.Sprintf(
+		`%s<br><div class="gmail_quote"><div>%s</div><blockquote class="gmail_quote" style="margin:0 0 0 .8ex;border-left:1px #ccc solid;padding-left:1ex">%s</blockquote></div>`,
+		htmlBody(msg.HTML, msg.Text),
+		html.EscapeString(attribution),
+		htmlBody(content.html, content.text),
+	)
+
+	return text, body
+}
+
+func forwardBodies(msg *models.OutgoingMessage, headers map[string]string, content *originalContent) (string, string) {
+	summary := []string{"---------- Forwarded message ---------"}
+	for _, key := range []string{"From", "Date", "Subject", "To", "Cc"} {
+		if headers[key] != "" {
+			summary = append(summary, fmt.Sprintf("%s: %s", key, extractor.DecodeHeader(headers[key])))
+		}
+	}
+
+	originalText := content.text
+	if originalText == "" {
+		originalText = stripLines(content.html)
+	}
+	text := strings.Join([]string{msg.Text, "", strings.Join(summary, "\n"), "", originalText}, "\n")
+
+	body := fmt.Sprintf(
+		`%s<br><div class="gmail_quote">%s<br><br>%s</div>`,
+		htmlBody(msg.HTML, msg.Text),
+		strings.Join(escapeLines(summary), "<br>"),
+		htmlBody(content.html, content.text),
+	)
+
+	return text, body
+}
+
+// htmlBody returns the html body, or the escaped text body when the html one is missing.
+func htmlBody(htmlContent string, text string) string {
+	if htmlContent != "" {
+		return htmlContent
+	}
+	return strings.Join(escapeLines(strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")), "<br>")
+}
+
+func escapeLines(lines []string) []string {
+	escaped := make([]string, 0, len(lines))
+	for _, line := range lines {
+		escaped = append(escaped, html.EscapeString(line))
+	}
+	return escaped
+}
+
+// stripLines is a naive html to text conversion used when the original message has no text/plain part.
+func stripLines(content string) string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range content {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>':
+			inTag = false
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return html.UnescapeString(b.String())
+}
@@ -0,0 +1,307 @@
+package messages
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+type MockAttacher struct {
+	mock.Mock
+}
+
+func (m *MockAttacher) Get(ctx context.Context, userID string, messageID string, attachmentID string) google.AttacherClient {
+	args := m.Called(ctx, userID, messageID, attachmentID)
+	return args.Get(0).(google.AttacherClient)
+}
+
+type MockAttacherClient struct {
+	mock.Mock
+}
+
+func (m *MockAttacherClient) Do(opts ...googleapi.CallOption) (*gmail.MessagePartBody, error) {
+	args := m.Called(opts)
+	return args.Get(0).(*gmail.MessagePartBody), args.Error(1)
+}
+
+func encodeData(data string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(data))
+}
+
+func originalMessage(labels ...string) *gmail.Message {
+	return &gmail.Message{
+		Id:       "MSG_1",
+		ThreadId: "THREAD_1",
+		LabelIds: labels,
+		Payload: &gmail.MessagePart{
+			MimeType: "multipart/mixed",
+			Headers: []*gmail.MessagePartHeader{
+				{Name: "From", Value: "Alice <alice@example.com>"},
+				{Name: "To", Value: "Me <me@example.com>, Bob <bob@example.com>"},
+				{Name: "Cc", Value: "carol@example.com"},
+				{Name: "Delivered-To", Value: "me@example.com"},
+				{Name: "Subject", Value: "Quarterly report"},
+				{Name: "Date", Value: "Mon, 2 Jan 2006 15:04:05 -0700"},
+				{Name: "Message-ID", Value: "<original@example.com>"},
+				{Name: "References", Value: "<first@example.com>"},
+			},
+			Parts: []*gmail.MessagePart{
+				{
+					MimeType: "multipart/alternative",
+					Parts: []*gmail.MessagePart{
+						{MimeType: "text/plain", Body: &gmail.MessagePartBody{Data: encodeData("line one\nline two")}},
+						{MimeType: "text/html", Body: &gmail.MessagePartBody{Data: encodeData("<p>line one</p>")}},
+					},
+				},
+				{
+					MimeType: "application/pdf",
+					Filename: "report.pdf",
+					Body:     &gmail.MessagePartBody{AttachmentId: "ATTACHMENT_1", Size: 3},
+				},
+			},
+		},
+	}
+}
+
+// sentMessage decodes the raw message captured by the messenger Send mock.
+func sentMessage(t *testing.T, messenger *MockMessenger) (*gmail.Message, *mail.Message) {
+	for _, call := range messenger.Calls {
+		if call.Method != "Send" {
+			continue
+		}
+		message := call.Arguments.Get(2).(*gmail.Message)
+		raw, err := base64.URLEncoding.DecodeString(message.Raw)
+		if err != nil {
+			t.Fatalf("cannot decode raw message: %v", err)
+		}
+		parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+		if err != nil {
+			t.Fatalf("cannot parse raw message: %v", err)
+		}
+		return message, parsed
+	}
+	t.Fatal("message was not sent")
+	return nil, nil
+}
+
+func addressEmails(t *testing.T, header mail.Header, key string) []string {
+	if header.Get(key) == "" {
+		return nil
+	}
+	list, err := header.AddressList(key)
+	if err != nil {
+		t.Fatalf("cannot parse %s header: %v", key, err)
+	}
+	emails := make([]string, 0, len(list))
+	for _, address := range list {
+		emails = append(emails, address.Address)
+	}
+	return emails
+}
+
+func newRespondService(original *gmail.Message) (Service, *MockMessenger, *MockAttacher) {
+	getCall := &MockMessengerClientResp{}
+	getCall.On("Do", []googleapi.CallOption(nil)).Return(original, nil)
+	sendCall := &MockMessengerClientResp{}
+	sendCall.On("Do", []googleapi.CallOption(nil)).Return(&gmail.Message{Id: "MSG_2", ThreadId: original.ThreadId}, nil)
+
+	messenger := &MockMessenger{}
+	messenger.On("Get", mock.Anything, "1", original.Id).Return(getCall)
+	messenger.On("Send", mock.Anything, "1", mock.AnythingOfType("*gmail.Message")).Return(sendCall)
+
+	attachmentCall := &MockAttacherClient{}
+	attachmentCall.On("Do", []googleapi.CallOption(nil)).Return(&gmail.MessagePartBody{Data: encodeData("pdf")}, nil)
+	attacher := &MockAttacher{}
+	attacher.On("Get", mock.Anything, "1", original.Id, "ATTACHMENT_1").Return(attachmentCall)
+
+	gmailSvc := &MockGmailService{}
+	gmailSvc.On("GetMessagesService").Return(messenger)
+	gmailSvc.On("GetAttachmentsService").Return(attacher)
+	mailxSvc := &MockMailxService{}
+	mailxSvc.On("GetGmailService", "1").Return(gmailSvc)
+
+	return New(log.NewLogfmtLogger(os.Stdin), nil, mailxSvc), messenger, attacher
+}
+
+func TestReplyMessage(t *testing.T) {
+	testcases := []struct {
+		name       string
+		original   *gmail.Message
+		respond    func(Service) (*gmail.Message, error)
+		expectedTo []string
+		expectedCc []string
+	}{
+		{
+			name:     "success - reply goes to the original sender.",
+			original: originalMessage("INBOX"),
+			respond: func(s Service) (*gmail.Message, error) {
+				return s.ReplyMessage(context.Background(), "1", "MSG_1", &models.OutgoingMessage{Text: "Thanks!"})
+			},
+			expectedTo: []string{"alice@example.com"},
+		},
+		{
+			name:     "success - reply all goes to every recipient except the user.",
+			original: originalMessage("INBOX"),
+			respond: func(s Service) (*gmail.Message, error) {
+				return s.ReplyAllMessage(context.Background(), "1", "MSG_1", &models.OutgoingMessage{Text: "Thanks!"})
+			},
+			expectedTo: []string{"alice@example.com", "bob@example.com"},
+			expectedCc: []string{"carol@example.com"},
+		},
+		{
+			name:     "success - reply to a sent message goes to the original recipients.",
+			original: originalMessage("SENT"),
+			respond: func(s Service) (*gmail.Message, error) {
+				return s.ReplyMessage(context.Background(), "1", "MSG_1", &models.OutgoingMessage{Text: "Following up"})
+			},
+			expectedTo: []string{"me@example.com", "bob@example.com"},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			svc, messenger, _ := newRespondService(test.original)
+			sent, err := test.respond(svc)
+			assert.Nil(t, err)
+			assert.NotNil(t, sent)
+
+			message, parsed := sentMessage(t, messenger)
+			assert.Equal(t, "THREAD_1", message.ThreadId)
+			assert.Equal(t, "<original@example.com>", parsed.Header.Get("In-Reply-To"))
+			assert.Equal(t, "<first@example.com> <original@example.com>", parsed.Header.Get("References"))
+			assert.Equal(t, "Re: Quarterly report", parsed.Header.Get("Subject"))
+			assert.Equal(t, test.expectedTo, addressEmails(t, parsed.Header, "To"))
+			assert.Equal(t, test.expectedCc, addressEmails(t, parsed.Header, "Cc"))
+
+			body, err := io.ReadAll(parsed.Body)
+			assert.Nil(t, err)
+			assert.Contains(t, string(body), "> line one")
+			assert.Contains(t, string(body), "blockquote")
+		})
+	}
+}
+
+func TestForwardMessage(t *testing.T) {
+	t.Run("success - forward re-attaches the original attachments.", func(t *testing.T) {
+		svc, messenger, attacher := newRespondService(originalMessage("INBOX"))
+		sent, err := svc.ForwardMessage(context.Background(), "1", "MSG_1", &models.OutgoingMessage{
+			To:   []models.Address{{Email: "dave@example.com"}},
+			Text: "FYI",
+		})
+		assert.Nil(t, err)
+		assert.NotNil(t, sent)
+		attacher.AssertCalled(t, "Get", context.Background(), "1", "MSG_1", "ATTACHMENT_1")
+
+		message, parsed := sentMessage(t, messenger)
+		assert.Equal(t, "THREAD_1", message.ThreadId)
+		assert.Equal(t, "Fwd: Quarterly report", parsed.Header.Get("Subject"))
+		assert.Equal(t, []string{"dave@example.com"}, addressEmails(t, parsed.Header, "To"))
+
+		_, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+		assert.Nil(t, err)
+		reader := multipart.NewReader(parsed.Body, params["boundary"])
+
+		body, err := reader.NextRawPart()
+		assert.Nil(t, err)
+		content, err := io.ReadAll(body)
+		assert.Nil(t, err)
+		assert.Contains(t, string(content), "Forwarded message")
+
+		attachment, err := reader.NextRawPart()
+		assert.Nil(t, err)
+		assert.Equal(t, "report.pdf", attachment.FileName())
+		data, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, attachment))
+		assert.Nil(t, err)
+		assert.Equal(t, "pdf", string(data))
+	})
+
+	t.Run("failure - forward without recipients.", func(t *testing.T) {
+		svc, _, _ := newRespondService(originalMessage("INBOX"))
+		_, err := svc.ForwardMessage(context.Background(), "1", "MSG_1", &models.OutgoingMessage{Text: "FYI"})
+		assert.Equal(t, models.ErrInvalidData{Field: "to"}, err)
+	})
+}
+
+func TestRespondEncodedHeaders(t *testing.T) {
+	encodedMessage := func() *gmail.Message {
+		original := originalMessage("INBOX")
+		for _, header := range original.Payload.Headers {
+			switch header.Name {
+			case "Subject":
+				header.Value = mime.BEncoding.Encode("UTF-8", "Re: Café")
+			case "From":
+				header.Value = mime.QEncoding.Encode("UTF-8", "José") + " <jose@example.com>"
+			}
+		}
+		return original
+	}
+
+	testcases := []struct {
+		name            string
+		respond         func(Service) (*gmail.Message, error)
+		expectedSubject string
+		expectedBody    string
+	}{
+		{
+			name: "success - reply decodes the subject and the sender.",
+			respond: func(s Service) (*gmail.Message, error) {
+				return s.ReplyMessage(context.Background(), "1", "MSG_1", &models.OutgoingMessage{Text: "Thanks!"})
+			},
+			expectedSubject: "Re: Café",
+			expectedBody:    "José <jose@example.com> wrote:",
+		},
+		{
+			name: "success - forward decodes the subject and the sender.",
+			respond: func(s Service) (*gmail.Message, error) {
+				return s.ForwardMessage(context.Background(), "1", "MSG_1", &models.OutgoingMessage{
+					To:   []models.Address{{Email: "dave@example.com"}},
+					Text: "FYI",
+				})
+			},
+			expectedSubject: "Fwd: Re: Café",
+			expectedBody:    "From: José <jose@example.com>",
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			svc, messenger, _ := newRespondService(encodedMessage())
+			_, err := test.respond(svc)
+			assert.Nil(t, err)
+
+			_, parsed := sentMessage(t, messenger)
+			subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+			assert.Nil(t, err)
+			assert.Equal(t, test.expectedSubject, subject)
+
+			// the text parts are quoted printable, the boundaries and headers are not affected by decoding them.
+			body, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+			assert.Nil(t, err)
+			assert.NotContains(t, string(body), "=?UTF-8?")
+			assert.Contains(t, string(body), test.expectedBody)
+		})
+	}
+}
+
+func TestPrefixSubject(t *testing.T) {
+	assert.Equal(t, "Re: hello", prefixSubject("Re:", "hello", ""))
+	assert.Equal(t, "RE: hello", prefixSubject("Re:", "RE: hello", ""))
+	assert.Equal(t, "custom", prefixSubject("Re:", "hello", "custom"))
+	assert.True(t, strings.HasPrefix(prefixSubject("Fwd:", "", ""), "Fwd:"))
+}
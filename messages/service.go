@@ -4,62 +4,106 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
-	"sort"
+	"reflect"
+	"strings"
 	"sync"
-	"sync/atomic"
 
 	"github.com/go-kit/log"
 	"github.com/orlandorode97/mailx-google-service"
+	"github.com/orlandorode97/mailx-google-service/pkg/composer"
+	"github.com/orlandorode97/mailx-google-service/pkg/extractor"
 	"github.com/orlandorode97/mailx-google-service/pkg/google"
 	"github.com/orlandorode97/mailx-google-service/pkg/models"
 	"github.com/orlandorode97/mailx-google-service/pkg/repos"
+	"github.com/spf13/viper"
 	"google.golang.org/api/gmail/v1"
 )
 
 const (
+	// messagesLimit is the default page size when a client does not request one.
 	messagesLimit int64 = 10
+	// maxMessagesLimit caps the page size since every listed message is hydrated with its own request.
+	maxMessagesLimit int64 = 100
+	// defaultFetchConcurrency is the number of messages of a page fetched at once unless MESSAGES_FETCH_CONCURRENCY is set.
+	defaultFetchConcurrency = 10
 )
 
 type Service interface {
-	GetMessages(context.Context, string) ([]*models.Message, error)
+	GetMessages(context.Context, string, google.MessageListOptions) (*models.MessagesPage, error)
 	GetMessageByID(context.Context, string, string) (*models.Message, error)
+	// SendMessage composes a MIME message out of the outgoing message and sends it on behalf of the user.
+	SendMessage(context.Context, string, *models.OutgoingMessage) (*gmail.Message, error)
+	// ReplyMessage answers the sender of a message keeping the reply in the same thread.
+	ReplyMessage(context.Context, string, string, *models.OutgoingMessage) (*gmail.Message, error)
+	// ReplyAllMessage answers the sender and every recipient of a message keeping the reply in the same thread.
+	ReplyAllMessage(context.Context, string, string, *models.OutgoingMessage) (*gmail.Message, error)
+	// ForwardMessage sends a message along with its attachments to new recipients.
+	ForwardMessage(context.Context, string, string, *models.OutgoingMessage) (*gmail.Message, error)
+	// GetAttachment returns the description of an attachment and a reader of its decoded content.
+	GetAttachment(context.Context, string, string, string) (*models.AttachmentContent, error)
 }
 
 type service struct {
-	logger      log.Logger
-	repo        repos.Repository
-	mailxSvc    mailx.Service
-	messagesSvc google.Messenger
+	logger   log.Logger
+	repo     repos.Repository
+	mailxSvc mailx.Service
+	// concurrency bounds the messages of a page fetched at once, which keeps a page within the gmail rate limits.
+	concurrency int
 }
 
 func New(logger log.Logger, repo repos.Repository, mailx mailx.Service) Service {
+	concurrency := viper.GetInt("MESSAGES_FETCH_CONCURRENCY")
+	if concurrency <= 0 {
+		concurrency = defaultFetchConcurrency
+	}
+
 	return &service{
-		logger:   logger,
-		repo:     repo,
-		mailxSvc: mailx,
+		logger:      logger,
+		repo:        repo,
+		mailxSvc:    mailx,
+		concurrency: concurrency,
 	}
 }
 
-func (s *service) addMessagesService(svc google.Messenger) {
-	s.messagesSvc = svc
+func (s *service) getGmailService(userID string) google.Service {
+	svc := s.mailxSvc.GetGmailService(userID)
+	if svc == nil || (reflect.ValueOf(svc).Kind() == reflect.Ptr && reflect.ValueOf(svc).IsNil()) {
+		return nil
+	}
+	return svc
 }
 
-func (s *service) recreateMessageService(ctx context.Context, userID string) error {
-	svc, err := s.mailxSvc.RecreateGmailService(ctx, userID)
+// gmailService returns the gmail service attached to the user, recreating it when it is missing.
+func (s *service) gmailService(ctx context.Context, userID string) (google.Service, error) {
+	if svc := s.getGmailService(userID); svc != nil {
+		return svc, nil
+	}
+	return s.mailxSvc.RecreateGmailService(ctx, userID)
+}
+
+// messageService returns the messages service attached to the user, recreating the gmail service when it is missing.
+func (s *service) messageService(ctx context.Context, userID string) (google.Messenger, error) {
+	svc, err := s.gmailService(ctx, userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	s.addMessagesService(svc.GetMessagesService())
-	return nil
+	return svc.GetMessagesService(), nil
 }
 
-func (s *service) GetMessages(ctx context.Context, userID string) ([]*models.Message, error) {
+func (s *service) GetMessages(ctx context.Context, userID string, opts google.MessageListOptions) (*models.MessagesPage, error) {
+	switch {
+	case opts.MaxResults < 0 || opts.MaxResults > maxMessagesLimit:
+		return nil, models.ErrInvalidData{Field: "page_size"}
+	case opts.MaxResults == 0:
+		opts.MaxResults = messagesLimit
+	}
 
-	if s.messagesSvc == nil {
-		s.recreateMessageService(context.Background(), userID)
+	svc, err := s.messageService(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
 
-	messagesResp, err := s.messagesSvc.List(userID, messagesLimit).Do()
+	messagesResp, err := svc.List(ctx, userID, opts).Do()
 	if err != nil {
 		s.logger.Log(
 			"message", fmt.Sprintf("error getting messages for user=%s", userID),
@@ -68,52 +112,98 @@ func (s *service) GetMessages(ctx context.Context, userID string) ([]*models.Mes
 		)
 		return nil, err
 	}
-	fmt.Printf("length alv %v \n\n\n\n", len(messagesResp.Messages))
 	s.logger.Log(
 		"message", fmt.Sprintf("get messages for user=%s", userID),
 		"severity", "INFO",
 	)
 
-	messageChan := make(chan *models.Message)
+	messageIDs := make([]string, 0, len(messagesResp.Messages))
+	for _, message := range messagesResp.Messages {
+		messageIDs = append(messageIDs, message.Id)
+	}
 
-	messages := make([]*models.Message, 0)
-	var counter int32 = 0
-	var wg sync.WaitGroup
-	go func() {
-		for message := range messageChan {
-			messages = append(messages, message)
-		}
-	}()
+	messages, messageErrs, err := s.fetchMessages(ctx, svc, userID, messageIDs)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, message := range messagesResp.Messages {
+	return &models.MessagesPage{
+		Messages:           messages,
+		Errors:             messageErrs,
+		NextPageToken:      messagesResp.NextPageToken,
+		ResultSizeEstimate: messagesResp.ResultSizeEstimate,
+	}, nil
+}
+
+/*
+fetchMessages hydrates the messages with at most s.concurrency of them fetched at once. The messages keep the
+order of messageIDs, the ones that fail are reported in the errors instead of failing the whole page.
+An error is only returned when ctx is done before every message was fetched.
+*/
+func (s *service) fetchMessages(ctx context.Context, svc google.Messenger, userID string, messageIDs []string) ([]*models.Message, []models.MessageError, error) {
+	results := make([]*models.Message, len(messageIDs))
+	errs := make([]error, len(messageIDs))
+
+	workers := s.concurrency
+	if workers <= 0 {
+		workers = defaultFetchConcurrency
+	}
+	if workers > len(messageIDs) {
+		workers = len(messageIDs)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
 		wg.Add(1)
-		go func(userID, messageID string) {
-			msg, err := s.GetMessageByID(ctx, userID, messageID)
-			if err != nil {
-				return
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], errs[i] = s.getMessage(ctx, svc, userID, messageIDs[i])
 			}
-			messageChan <- msg
-			atomic.AddInt32(&counter, 1)
-			wg.Done()
-		}(userID, message.Id)
+		}()
 	}
 
+feed:
+	for i := range messageIDs {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
 	wg.Wait()
-	fmt.Println("total of items", counter)
-	fmt.Printf("length alv of messages %v \n\n\n\n", len(messages))
-	sort.Slice(messages, func(i, j int) bool {
-		return messages[i].InternalDate > messages[j].InternalDate
-	})
-	return messages, nil
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	messages := make([]*models.Message, 0, len(messageIDs))
+	var messageErrs []models.MessageError
+	for i, messageID := range messageIDs {
+		if errs[i] != nil {
+			messageErrs = append(messageErrs, models.MessageError{MessageID: messageID, Error: errs[i].Error()})
+			continue
+		}
+		messages = append(messages, results[i])
+	}
+
+	return messages, messageErrs, nil
 }
 
 func (s *service) GetMessageByID(ctx context.Context, userID string, messageID string) (*models.Message, error) {
-
-	if s.messagesSvc == nil {
-		s.recreateMessageService(context.Background(), userID)
+	svc, err := s.messageService(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
 
-	message, err := s.messagesSvc.Get(userID, messageID).Do()
+	return s.getMessage(ctx, svc, userID, messageID)
+}
+
+// getMessage fetches and hydrates a single message through the given user messages service.
+func (s *service) getMessage(ctx context.Context, svc google.Messenger, userID string, messageID string) (*models.Message, error) {
+	message, err := svc.Get(ctx, userID, messageID).Do()
 	if err != nil {
 		s.logger.Log(
 			"message", fmt.Sprintf("error message=%s for user= %s", messageID, userID),
@@ -128,7 +218,7 @@ func (s *service) GetMessageByID(ctx context.Context, userID string, messageID s
 		"severity", "INFO",
 	)
 
-	msg, err := s.hydrateMessage(message)
+	msg, err := HydrateMessage(message)
 	if err != nil {
 		return nil, err
 	}
@@ -136,41 +226,109 @@ func (s *service) GetMessageByID(ctx context.Context, userID string, messageID s
 	return msg, nil
 }
 
-func (s *service) hydrateMessage(message *gmail.Message) (*models.Message, error) {
-	var (
-		data string
-		html []byte
-		err  error
+func (s *service) SendMessage(ctx context.Context, userID string, msg *models.OutgoingMessage) (*gmail.Message, error) {
+	raw, err := composer.Raw(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, err := s.messageService(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sent, err := svc.Send(ctx, userID, &gmail.Message{Raw: raw, ThreadId: msg.ThreadID}).Do()
+	if err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error sending message for user=%s", userID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return nil, err
+	}
+
+	s.logger.Log(
+		"message", fmt.Sprintf("send message=%s for user=%s", sent.Id, userID),
+		"severity", "INFO",
 	)
 
-	for _, part := range message.Payload.Parts {
-		if data != "" {
-			break
-		}
-		// Some messages include the html to decode in []*gmail.MessagePart from the parent *gmail.MessagePart
-		if part.Parts != nil {
-			for _, p := range part.Parts {
-				if p.MimeType == "text/html" {
-					data = p.Body.Data
-					break
-				}
-			}
-		}
+	return sent, nil
+}
 
-		if part.MimeType == "text/html" {
-			data = part.Body.Data
-			break
-		}
+func (s *service) GetAttachment(ctx context.Context, userID string, messageID string, attachmentID string) (*models.AttachmentContent, error) {
+	gmailSvc, err := s.gmailService(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := gmailSvc.GetMessagesService().Get(ctx, userID, messageID).Do()
+	if err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error message=%s for user= %s", messageID, userID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return nil, err
+	}
+
+	attachment := findAttachment(message.Payload, attachmentID)
+
+	body, err := gmailSvc.GetAttachmentsService().Get(ctx, userID, messageID, attachmentID).Do()
+	if err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error getting attachment=%s of message=%s for user=%s", attachmentID, messageID, userID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return nil, err
+	}
+
+	s.logger.Log(
+		"message", fmt.Sprintf("get attachment=%s of message=%s for user=%s", attachmentID, messageID, userID),
+		"severity", "INFO",
+	)
+
+	if body.Size > 0 {
+		attachment.Size = body.Size
 	}
 
-	if data != "" {
-		html, err = base64.URLEncoding.DecodeString(data)
-		if err != nil {
-			return nil, err
+	return &models.AttachmentContent{
+		MessageAttachment: *attachment,
+		Content:           base64.NewDecoder(base64.RawURLEncoding, strings.NewReader(strings.TrimRight(body.Data, "="))),
+	}, nil
+}
+
+// findAttachment looks for the attachment in the message parts. Gmail does not always return the same attachment ID
+// for the same part, so it falls back to the only attachment of the message or to a generic binary description.
+func findAttachment(payload *gmail.MessagePart, attachmentID string) *models.MessageAttachment {
+	attachments := attachmentManifest(payload)
+	for _, attachment := range attachments {
+		if attachment.AttachmentID == attachmentID {
+			return attachment
 		}
 	}
 
-	return &models.Message{
+	if len(attachments) == 1 {
+		attachment := *attachments[0]
+		attachment.AttachmentID = attachmentID
+		return &attachment
+	}
+
+	return &models.MessageAttachment{
+		AttachmentID: attachmentID,
+		Filename:     "attachment",
+		MimeType:     "application/octet-stream",
+	}
+}
+
+// HydrateMessage converts a gmail message into a message with its decoded bodies, parsed headers and attachment manifest.
+func HydrateMessage(message *gmail.Message) (*models.Message, error) {
+	body, err := extractor.Extract(message.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &models.Message{
 		ID:           message.Id,
 		HistoryID:    message.HistoryId,
 		InternalDate: message.InternalDate,
@@ -179,6 +337,29 @@ func (s *service) hydrateMessage(message *gmail.Message) (*models.Message, error
 		SizeEstimate: message.SizeEstimate,
 		Snippet:      message.Snippet,
 		ThreadID:     message.ThreadId,
-		HTML:         string(html),
-	}, nil
+		HTML:         body.HTML,
+		Text:         body.Text,
+		Attachments:  attachmentManifest(message.Payload),
+	}
+	applyHeaders(msg, message.Payload)
+
+	return msg, nil
+}
+
+// attachmentManifest lists the attachments of a message without downloading them.
+func attachmentManifest(payload *gmail.MessagePart) []*models.MessageAttachment {
+	attachments := make([]*models.MessageAttachment, 0)
+	_ = extractor.Walk(payload, func(part *gmail.MessagePart) error {
+		if extractor.IsAttachment(part) && part.Body != nil {
+			attachments = append(attachments, &models.MessageAttachment{
+				AttachmentID: part.Body.AttachmentId,
+				PartID:       part.PartId,
+				Filename:     part.Filename,
+				MimeType:     part.MimeType,
+				Size:         part.Body.Size,
+			})
+		}
+		return nil
+	})
+	return attachments
 }
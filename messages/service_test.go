@@ -0,0 +1,694 @@
+package messages
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/orlandorode97/mailx-google-service"
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+type MockGmailService struct {
+	mock.Mock
+}
+
+func (m *MockGmailService) GetLabelsService() google.Labeler {
+	args := m.Called()
+	return args.Get(0).(google.Labeler)
+}
+
+func (m *MockGmailService) GetMessagesService() google.Messenger {
+	args := m.Called()
+	return args.Get(0).(google.Messenger)
+}
+
+func (m *MockGmailService) GetAttachmentsService() google.Attacher {
+	args := m.Called()
+	return args.Get(0).(google.Attacher)
+}
+
+func (m *MockGmailService) GetThreadsService() google.Threader {
+	args := m.Called()
+	return args.Get(0).(google.Threader)
+}
+
+func (m *MockGmailService) GetDraftsService() google.Drafter {
+	args := m.Called()
+	return args.Get(0).(google.Drafter)
+}
+
+func (m *MockGmailService) GetHistoryService() google.Historian {
+	args := m.Called()
+	return args.Get(0).(google.Historian)
+}
+
+type MockMailxService struct {
+	mock.Mock
+}
+
+func (m *MockMailxService) GetGmailService(userID string) google.Service {
+	args := m.Called(userID)
+	return args.Get(0).(google.Service)
+}
+
+func (m *MockMailxService) CreateGmailService(ctx context.Context, userID string, token *oauth2.Token) (google.Service, error) {
+	args := m.Called(ctx, userID, token)
+	return args.Get(0).(google.Service), args.Error(1)
+}
+
+func (m *MockMailxService) AddGmailServiceByID(ID string, gmailSvc google.Service) google.Service {
+	args := m.Called(ID, gmailSvc)
+	return args.Get(0).(google.Service)
+}
+
+func (m *MockMailxService) Evict(ID string) {
+	m.Called(ID)
+}
+
+func (m *MockMailxService) CacheStats() mailx.CacheStats {
+	args := m.Called()
+	return args.Get(0).(mailx.CacheStats)
+}
+
+func (m *MockMailxService) RecreateGmailService(ctx context.Context, ID string) (google.Service, error) {
+	args := m.Called(ctx, ID)
+	return args.Get(0).(google.Service), args.Error(1)
+}
+
+type MockMessenger struct {
+	mock.Mock
+}
+
+func (m *MockMessenger) BatchDelete(ctx context.Context, userID string, req *gmail.BatchDeleteMessagesRequest) google.MessengerClient {
+	args := m.Called(ctx, userID, req)
+	return args.Get(0).(google.MessengerClient)
+}
+
+func (m *MockMessenger) BatchModify(ctx context.Context, userID string, req *gmail.BatchModifyMessagesRequest) google.MessengerClient {
+	args := m.Called(ctx, userID, req)
+	return args.Get(0).(google.MessengerClient)
+}
+
+func (m *MockMessenger) Delete(ctx context.Context, userID string, messageID string) google.MessengerClient {
+	args := m.Called(ctx, userID, messageID)
+	return args.Get(0).(google.MessengerClient)
+}
+
+func (m *MockMessenger) Get(ctx context.Context, userID string, messageID string) google.MessengerClientResp {
+	args := m.Called(ctx, userID, messageID)
+	return args.Get(0).(google.MessengerClientResp)
+}
+
+func (m *MockMessenger) Import(ctx context.Context, userID string, message *gmail.Message) google.MessengerClientResp {
+	args := m.Called(ctx, userID, message)
+	return args.Get(0).(google.MessengerClientResp)
+}
+
+func (m *MockMessenger) Insert(ctx context.Context, userID string, message *gmail.Message) google.MessengerClientResp {
+	args := m.Called(ctx, userID, message)
+	return args.Get(0).(google.MessengerClientResp)
+}
+
+func (m *MockMessenger) List(ctx context.Context, userID string, opts google.MessageListOptions) google.MessengerClientList {
+	args := m.Called(ctx, userID, opts)
+	return args.Get(0).(google.MessengerClientList)
+}
+
+func (m *MockMessenger) Modify(ctx context.Context, userID string, messageID string, req *gmail.ModifyMessageRequest) google.MessengerClientResp {
+	args := m.Called(ctx, userID, messageID, req)
+	return args.Get(0).(google.MessengerClientResp)
+}
+
+func (m *MockMessenger) Send(ctx context.Context, userID string, message *gmail.Message) google.MessengerClientResp {
+	args := m.Called(ctx, userID, message)
+	return args.Get(0).(google.MessengerClientResp)
+}
+
+func (m *MockMessenger) Trash(ctx context.Context, userID string, messageID string) google.MessengerClientResp {
+	args := m.Called(ctx, userID, messageID)
+	return args.Get(0).(google.MessengerClientResp)
+}
+
+func (m *MockMessenger) Untrash(ctx context.Context, userID string, messageID string) google.MessengerClientResp {
+	args := m.Called(ctx, userID, messageID)
+	return args.Get(0).(google.MessengerClientResp)
+}
+
+type MockMessengerClientResp struct {
+	mock.Mock
+}
+
+func (m *MockMessengerClientResp) Do(opts ...googleapi.CallOption) (*gmail.Message, error) {
+	args := m.Called(opts)
+	return args.Get(0).(*gmail.Message), args.Error(1)
+}
+
+type MockMessengerClientList struct {
+	mock.Mock
+}
+
+func (m *MockMessengerClientList) Do(opts ...googleapi.CallOption) (*gmail.ListMessagesResponse, error) {
+	args := m.Called(opts)
+	return args.Get(0).(*gmail.ListMessagesResponse), args.Error(1)
+}
+
+// newUserMessenger returns a gmail service whose messages service only answers for the given user.
+func newUserMessenger(userID string, messageID string) *MockGmailService {
+	call := &MockMessengerClientResp{}
+	call.On("Do", []googleapi.CallOption(nil)).Return(&gmail.Message{
+		Id:      messageID,
+		Snippet: fmt.Sprintf("message of user=%s", userID),
+		Payload: &gmail.MessagePart{},
+	}, nil)
+
+	messenger := &MockMessenger{}
+	messenger.On("Get", mock.Anything, userID, messageID).Return(call)
+
+	gmailSvc := &MockGmailService{}
+	gmailSvc.On("GetMessagesService").Return(messenger)
+	return gmailSvc
+}
+
+func TestGetMessageByID(t *testing.T) {
+	testcases := []struct {
+		name                string
+		ctx                 context.Context
+		userID              string
+		messageID           string
+		isGmailSvcNil       bool
+		errGmailSvcRecreate error
+		message             *gmail.Message
+		errMessage          error
+		assertErr           func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+		assertMessage       func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+	}{
+		{
+			name:      "success - message returned by the gmail api.",
+			ctx:       context.Background(),
+			userID:    "1",
+			messageID: "MSG_1",
+			message: &gmail.Message{
+				Id:      "MSG_1",
+				Payload: &gmail.MessagePart{},
+			},
+			assertErr:     assert.Nil,
+			assertMessage: assert.NotNil,
+		},
+		{
+			name:          "success - gmail service is recreated before getting the message.",
+			ctx:           context.Background(),
+			userID:        "1",
+			messageID:     "MSG_1",
+			isGmailSvcNil: true,
+			message: &gmail.Message{
+				Id:      "MSG_1",
+				Payload: &gmail.MessagePart{},
+			},
+			assertErr:     assert.Nil,
+			assertMessage: assert.NotNil,
+		},
+		{
+			name:                "failure - cannot recreate gmail service.",
+			ctx:                 context.Background(),
+			userID:              "1",
+			messageID:           "MSG_1",
+			isGmailSvcNil:       true,
+			errGmailSvcRecreate: errors.New("Cannot recreate gmail service"),
+			message:             &gmail.Message{},
+			assertErr:           assert.NotNil,
+			assertMessage:       assert.Nil,
+		},
+		{
+			name:          "failure - gmail messages service responds an error.",
+			ctx:           context.Background(),
+			userID:        "1",
+			messageID:     "MSG_1",
+			message:       &gmail.Message{},
+			errMessage:    errors.New("requested entity was not found"),
+			assertErr:     assert.NotNil,
+			assertMessage: assert.Nil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			logger := log.NewLogfmtLogger(os.Stdin)
+			mockGmailService := &MockGmailService{}
+			mailxSvc := &MockMailxService{}
+			messenger := &MockMessenger{}
+			call := &MockMessengerClientResp{}
+
+			call.On("Do", []googleapi.CallOption(nil)).Return(test.message, test.errMessage)
+			messenger.On("Get", mock.Anything, test.userID, test.messageID).Return(call)
+			mockGmailService.On("GetMessagesService").Return(messenger)
+
+			if test.isGmailSvcNil {
+				mailxSvc.On("GetGmailService", test.userID).Return((*MockGmailService)(nil))
+				mailxSvc.On("RecreateGmailService", test.ctx, test.userID).Return(mockGmailService, test.errGmailSvcRecreate)
+			}
+
+			if !test.isGmailSvcNil {
+				mailxSvc.On("GetGmailService", test.userID).Return(mockGmailService)
+			}
+
+			messagesSvc := New(logger, nil, mailxSvc)
+			message, err := messagesSvc.GetMessageByID(test.ctx, test.userID, test.messageID)
+			test.assertErr(t, err)
+			test.assertMessage(t, message)
+		})
+	}
+}
+
+func TestGetMessageByIDConcurrentUsers(t *testing.T) {
+	t.Run("success - each user request is served by its own gmail service.", func(t *testing.T) {
+		ctx := context.Background()
+		logger := log.NewLogfmtLogger(os.Stdin)
+		mailxSvc := &MockMailxService{}
+
+		// The first user has a cached gmail service while the second one has to be recreated.
+		mailxSvc.On("GetGmailService", "1").Return(newUserMessenger("1", "MSG_1"))
+		mailxSvc.On("GetGmailService", "2").Return((*MockGmailService)(nil))
+		mailxSvc.On("RecreateGmailService", ctx, "2").Return(newUserMessenger("2", "MSG_2"), nil)
+
+		messagesSvc := New(logger, nil, mailxSvc)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			for _, userID := range []string{"1", "2"} {
+				wg.Add(1)
+				go func(userID string) {
+					defer wg.Done()
+					messageID := fmt.Sprintf("MSG_%s", userID)
+					message, err := messagesSvc.GetMessageByID(ctx, userID, messageID)
+					assert.Nil(t, err)
+					if assert.NotNil(t, message) {
+						assert.Equal(t, messageID, message.ID)
+						assert.Equal(t, fmt.Sprintf("message of user=%s", userID), message.Snippet)
+					}
+				}(userID)
+			}
+		}
+		wg.Wait()
+	})
+}
+
+func TestGetMessages(t *testing.T) {
+	testcases := []struct {
+		name             string
+		ctx              context.Context
+		userID           string
+		opts             google.MessageListOptions
+		expectedOpts     google.MessageListOptions
+		listResponse     *gmail.ListMessagesResponse
+		errList          error
+		assertErr        func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+		expectedToken    string
+		expectedEstimate int64
+	}{
+		{
+			name:   "success - page token and filters are sent to the gmail api.",
+			ctx:    context.Background(),
+			userID: "1",
+			opts: google.MessageListOptions{
+				MaxResults: 25,
+				PageToken:  "page-2",
+				Query:      "from:someone@example.com",
+				LabelIDs:   []string{"INBOX", "UNREAD"},
+			},
+			expectedOpts: google.MessageListOptions{
+				MaxResults: 25,
+				PageToken:  "page-2",
+				Query:      "from:someone@example.com",
+				LabelIDs:   []string{"INBOX", "UNREAD"},
+			},
+			listResponse: &gmail.ListMessagesResponse{
+				NextPageToken:      "page-3",
+				ResultSizeEstimate: 120,
+			},
+			assertErr:        assert.Nil,
+			expectedToken:    "page-3",
+			expectedEstimate: 120,
+		},
+		{
+			name:   "success - default page size is used when it is not requested.",
+			ctx:    context.Background(),
+			userID: "1",
+			expectedOpts: google.MessageListOptions{
+				MaxResults: messagesLimit,
+			},
+			listResponse: &gmail.ListMessagesResponse{},
+			assertErr:    assert.Nil,
+		},
+		{
+			name:   "failure - page size exceeds the limit.",
+			ctx:    context.Background(),
+			userID: "1",
+			opts: google.MessageListOptions{
+				MaxResults: maxMessagesLimit + 1,
+			},
+			listResponse: &gmail.ListMessagesResponse{},
+			assertErr:    assert.NotNil,
+		},
+		{
+			name:   "failure - gmail messages service responds an error.",
+			ctx:    context.Background(),
+			userID: "1",
+			expectedOpts: google.MessageListOptions{
+				MaxResults: messagesLimit,
+			},
+			listResponse: &gmail.ListMessagesResponse{},
+			errList:      errors.New("invalid page token"),
+			assertErr:    assert.NotNil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			logger := log.NewLogfmtLogger(os.Stdin)
+			mockGmailService := &MockGmailService{}
+			mailxSvc := &MockMailxService{}
+			messenger := &MockMessenger{}
+			call := &MockMessengerClientList{}
+
+			call.On("Do", []googleapi.CallOption(nil)).Return(test.listResponse, test.errList)
+			messenger.On("List", mock.Anything, test.userID, test.expectedOpts).Return(call)
+			mockGmailService.On("GetMessagesService").Return(messenger)
+			mailxSvc.On("GetGmailService", test.userID).Return(mockGmailService)
+
+			messagesSvc := New(logger, nil, mailxSvc)
+			page, err := messagesSvc.GetMessages(test.ctx, test.userID, test.opts)
+			test.assertErr(t, err)
+			if err == nil {
+				assert.Equal(t, test.expectedToken, page.NextPageToken)
+				assert.Equal(t, test.expectedEstimate, page.ResultSizeEstimate)
+			}
+		})
+	}
+}
+
+func TestGetMessagesFetch(t *testing.T) {
+	const concurrency = 3
+	listed := make([]*gmail.Message, 0, 20)
+	for i := 0; i < 20; i++ {
+		listed = append(listed, &gmail.Message{Id: fmt.Sprintf("MSG_%d", i)})
+	}
+
+	newMessagesService := func(ctx context.Context, failed map[string]bool) (Service, *int32) {
+		var inFlight, maxInFlight int32
+		messenger := &MockMessenger{}
+		for _, message := range listed {
+			call := &MockMessengerClientResp{}
+			var errGet error
+			if failed[message.Id] {
+				errGet = errors.New("requested entity was not found")
+			}
+			call.On("Do", []googleapi.CallOption(nil)).
+				Run(func(args mock.Arguments) {
+					current := atomic.AddInt32(&inFlight, 1)
+					for {
+						max := atomic.LoadInt32(&maxInFlight)
+						if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+							break
+						}
+					}
+					time.Sleep(time.Millisecond)
+					atomic.AddInt32(&inFlight, -1)
+				}).
+				Return(&gmail.Message{Id: message.Id, Payload: &gmail.MessagePart{}}, errGet)
+			messenger.On("Get", ctx, "1", message.Id).Return(call)
+		}
+
+		listCall := &MockMessengerClientList{}
+		listCall.On("Do", []googleapi.CallOption(nil)).Return(&gmail.ListMessagesResponse{Messages: listed}, nil)
+		messenger.On("List", ctx, "1", google.MessageListOptions{MaxResults: messagesLimit}).Return(listCall)
+
+		gmailSvc := &MockGmailService{}
+		gmailSvc.On("GetMessagesService").Return(messenger)
+		mailxSvc := &MockMailxService{}
+		mailxSvc.On("GetGmailService", "1").Return(gmailSvc)
+
+		return &service{
+			logger:      log.NewLogfmtLogger(os.Stdin),
+			mailxSvc:    mailxSvc,
+			concurrency: concurrency,
+		}, &maxInFlight
+	}
+
+	t.Run("success - messages keep the listed order within the concurrency limit.", func(t *testing.T) {
+		ctx := context.Background()
+		messagesSvc, maxInFlight := newMessagesService(ctx, nil)
+
+		page, err := messagesSvc.GetMessages(ctx, "1", google.MessageListOptions{})
+		assert.Nil(t, err)
+		assert.Nil(t, page.Errors)
+		assert.Len(t, page.Messages, len(listed))
+		for i, message := range page.Messages {
+			assert.Equal(t, listed[i].Id, message.ID)
+		}
+		assert.LessOrEqual(t, atomic.LoadInt32(maxInFlight), int32(concurrency))
+	})
+
+	t.Run("success - failed messages are reported without failing the page.", func(t *testing.T) {
+		ctx := context.Background()
+		messagesSvc, _ := newMessagesService(ctx, map[string]bool{"MSG_0": true, "MSG_7": true})
+
+		page, err := messagesSvc.GetMessages(ctx, "1", google.MessageListOptions{})
+		assert.Nil(t, err)
+		assert.Len(t, page.Messages, len(listed)-2)
+		assert.Equal(t, "MSG_1", page.Messages[0].ID)
+		assert.Equal(t, []models.MessageError{
+			{MessageID: "MSG_0", Error: "requested entity was not found"},
+			{MessageID: "MSG_7", Error: "requested entity was not found"},
+		}, page.Errors)
+	})
+
+	t.Run("success - failed messages are encoded under errors.", func(t *testing.T) {
+		ctx := context.Background()
+		messagesSvc, _ := newMessagesService(ctx, map[string]bool{"MSG_3": true})
+
+		response, err := MakeGetMessages(messagesSvc)(ctx, getMessagesRequest{UserID: "1"})
+		assert.Nil(t, err)
+		w := httptest.NewRecorder()
+		assert.Nil(t, encodeMessageResponse(ctx, w, response))
+
+		var body struct {
+			Messages []*models.Message     `json:"messages"`
+			Errors   []models.MessageError `json:"errors"`
+		}
+		assert.Nil(t, json.NewDecoder(w.Body).Decode(&body))
+		assert.Len(t, body.Messages, len(listed)-1)
+		assert.Equal(t, []models.MessageError{{MessageID: "MSG_3", Error: "requested entity was not found"}}, body.Errors)
+	})
+
+	t.Run("failure - every message failing does not block the request.", func(t *testing.T) {
+		ctx := context.Background()
+		failed := make(map[string]bool)
+		for _, message := range listed {
+			failed[message.Id] = true
+		}
+		messagesSvc, _ := newMessagesService(ctx, failed)
+
+		page, err := messagesSvc.GetMessages(ctx, "1", google.MessageListOptions{})
+		assert.Nil(t, err)
+		assert.Empty(t, page.Messages)
+		assert.Len(t, page.Errors, len(listed))
+	})
+
+	t.Run("failure - the request is canceled.", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		messagesSvc, _ := newMessagesService(ctx, nil)
+		cancel()
+
+		page, err := messagesSvc.GetMessages(ctx, "1", google.MessageListOptions{})
+		assert.Equal(t, context.Canceled, err)
+		assert.Nil(t, page)
+	})
+}
+
+func TestSendMessage(t *testing.T) {
+	testcases := []struct {
+		name      string
+		ctx       context.Context
+		userID    string
+		message   *models.OutgoingMessage
+		sent      *gmail.Message
+		errSend   error
+		assertErr func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+		assertMsg func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+	}{
+		{
+			name:   "success - composed message is sent by the gmail api.",
+			ctx:    context.Background(),
+			userID: "1",
+			message: &models.OutgoingMessage{
+				To:      []models.Address{{Name: "José", Email: "jose@example.com"}},
+				Subject: "Hello",
+				Text:    "Hello world",
+			},
+			sent: &gmail.Message{
+				Id:       "MSG_1",
+				ThreadId: "THREAD_1",
+			},
+			assertErr: assert.Nil,
+			assertMsg: assert.NotNil,
+		},
+		{
+			name:    "failure - message without recipients is not sent.",
+			ctx:     context.Background(),
+			userID:  "1",
+			message: &models.OutgoingMessage{Subject: "Hello"},
+			sent:    &gmail.Message{},
+			assertErr: func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool {
+				return assert.Equal(t, models.ErrInvalidData{Field: "to"}, object, msgAndArgs...)
+			},
+			assertMsg: assert.Nil,
+		},
+		{
+			name:   "failure - gmail messages service responds an error.",
+			ctx:    context.Background(),
+			userID: "1",
+			message: &models.OutgoingMessage{
+				To:   []models.Address{{Email: "jose@example.com"}},
+				Text: "Hello world",
+			},
+			sent:      &gmail.Message{},
+			errSend:   errors.New("invalid to header"),
+			assertErr: assert.NotNil,
+			assertMsg: assert.Nil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			logger := log.NewLogfmtLogger(os.Stdin)
+			mockGmailService := &MockGmailService{}
+			mailxSvc := &MockMailxService{}
+			messenger := &MockMessenger{}
+			call := &MockMessengerClientResp{}
+
+			call.On("Do", []googleapi.CallOption(nil)).Return(test.sent, test.errSend)
+			messenger.On("Send", mock.Anything, test.userID, mock.MatchedBy(func(message *gmail.Message) bool {
+				raw, err := base64.URLEncoding.DecodeString(message.Raw)
+				return err == nil && strings.Contains(string(raw), "jose@example.com")
+			})).Return(call)
+			mockGmailService.On("GetMessagesService").Return(messenger)
+			mailxSvc.On("GetGmailService", test.userID).Return(mockGmailService)
+
+			messagesSvc := New(logger, nil, mailxSvc)
+			sent, err := messagesSvc.SendMessage(test.ctx, test.userID, test.message)
+			test.assertErr(t, err)
+			test.assertMsg(t, sent)
+		})
+	}
+}
+
+func TestGetAttachment(t *testing.T) {
+	message := &gmail.Message{
+		Id: "MSG_1",
+		Payload: &gmail.MessagePart{
+			MimeType: "multipart/mixed",
+			Parts: []*gmail.MessagePart{
+				{MimeType: "text/plain", Body: &gmail.MessagePartBody{Data: encodeData("hello")}},
+				{PartId: "1", MimeType: "application/pdf", Filename: "report.pdf", Body: &gmail.MessagePartBody{AttachmentId: "ATTACHMENT_1", Size: 3}},
+				{PartId: "2", MimeType: "image/png", Filename: "logo.png", Body: &gmail.MessagePartBody{AttachmentId: "ATTACHMENT_2", Size: 4}},
+			},
+		},
+	}
+
+	testcases := []struct {
+		name             string
+		attachmentID     string
+		body             *gmail.MessagePartBody
+		errAttachment    error
+		assertErr        func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+		expectedFilename string
+		expectedMimeType string
+		expectedContent  string
+	}{
+		{
+			name:             "success - attachment content is decoded.",
+			attachmentID:     "ATTACHMENT_2",
+			body:             &gmail.MessagePartBody{Data: base64.URLEncoding.EncodeToString([]byte("png?")), Size: 4},
+			assertErr:        assert.Nil,
+			expectedFilename: "logo.png",
+			expectedMimeType: "image/png",
+			expectedContent:  "png?",
+		},
+		{
+			name:             "success - unknown attachment id is described as a binary file.",
+			attachmentID:     "ATTACHMENT_3",
+			body:             &gmail.MessagePartBody{Data: encodeData("data")},
+			assertErr:        assert.Nil,
+			expectedFilename: "attachment",
+			expectedMimeType: "application/octet-stream",
+			expectedContent:  "data",
+		},
+		{
+			name:          "failure - gmail attachments service responds an error.",
+			attachmentID:  "ATTACHMENT_1",
+			body:          &gmail.MessagePartBody{},
+			errAttachment: errors.New("invalid attachment token"),
+			assertErr:     assert.NotNil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			getCall := &MockMessengerClientResp{}
+			getCall.On("Do", []googleapi.CallOption(nil)).Return(message, nil)
+			messenger := &MockMessenger{}
+			messenger.On("Get", mock.Anything, "1", "MSG_1").Return(getCall)
+
+			attachmentCall := &MockAttacherClient{}
+			attachmentCall.On("Do", []googleapi.CallOption(nil)).Return(test.body, test.errAttachment)
+			attacher := &MockAttacher{}
+			attacher.On("Get", mock.Anything, "1", "MSG_1", test.attachmentID).Return(attachmentCall)
+
+			gmailSvc := &MockGmailService{}
+			gmailSvc.On("GetMessagesService").Return(messenger)
+			gmailSvc.On("GetAttachmentsService").Return(attacher)
+			mailxSvc := &MockMailxService{}
+			mailxSvc.On("GetGmailService", "1").Return(gmailSvc)
+
+			messagesSvc := New(log.NewLogfmtLogger(os.Stdin), nil, mailxSvc)
+			attachment, err := messagesSvc.GetAttachment(context.Background(), "1", "MSG_1", test.attachmentID)
+			test.assertErr(t, err)
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, test.expectedFilename, attachment.Filename)
+			assert.Equal(t, test.expectedMimeType, attachment.MimeType)
+			content, err := io.ReadAll(attachment.Content)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expectedContent, string(content))
+		})
+	}
+}
+
+func TestAttachmentManifest(t *testing.T) {
+	t.Run("success - nested attachments are listed.", func(t *testing.T) {
+		manifest := attachmentManifest(originalMessage("INBOX").Payload)
+		assert.Equal(t, []*models.MessageAttachment{
+			{AttachmentID: "ATTACHMENT_1", Filename: "report.pdf", MimeType: "application/pdf", Size: 3},
+		}, manifest)
+	})
+}
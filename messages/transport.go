@@ -3,6 +3,8 @@ package messages
 import (
 	"context"
 	"encoding/json"
+	"io"
+	"mime"
 	"net/http"
 
 	kithttp "github.com/go-kit/kit/transport/http"
@@ -10,6 +12,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/orlandorode97/mailx-google-service/pkg/middlewares"
 	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/orlandorode97/mailx-google-service/pkg/transport"
 )
 
 func MakeHandler(messagesService Service, logger log.Logger) http.Handler {
@@ -28,6 +31,51 @@ func MakeHandler(messagesService Service, logger log.Logger) http.Handler {
 			options...,
 		))
 
+	r.Methods(http.MethodPost).
+		Path("/messages/send").
+		Handler(kithttp.NewServer(
+			e.SendMessageEndpoint,
+			decodeSendMessageRequest,
+			encodeMessageResponse,
+			options...,
+		))
+
+	r.Methods(http.MethodPost).
+		Path("/messages/{message_id:[0-9a-zA-Z]+}/reply").
+		Handler(kithttp.NewServer(
+			e.ReplyMessageEndpoint,
+			decodeRespondMessageRequest,
+			encodeMessageResponse,
+			options...,
+		))
+
+	r.Methods(http.MethodPost).
+		Path("/messages/{message_id:[0-9a-zA-Z]+}/reply-all").
+		Handler(kithttp.NewServer(
+			e.ReplyAllMessageEndpoint,
+			decodeRespondMessageRequest,
+			encodeMessageResponse,
+			options...,
+		))
+
+	r.Methods(http.MethodPost).
+		Path("/messages/{message_id:[0-9a-zA-Z]+}/forward").
+		Handler(kithttp.NewServer(
+			e.ForwardMessageEndpoint,
+			decodeRespondMessageRequest,
+			encodeMessageResponse,
+			options...,
+		))
+
+	r.Methods(http.MethodGet).
+		Path("/messages/{message_id:[0-9a-zA-Z]+}/attachments/{attachment_id:[0-9a-zA-Z_\\-]+}").
+		Handler(kithttp.NewServer(
+			e.GetAttachmentEndpoint,
+			decodeAttachmentRequest,
+			encodeAttachmentResponse,
+			options...,
+		))
+
 	r.Methods(http.MethodGet).
 		Path("/messages/{message_id:[0-9a-zA-Z\\W]+|}").
 		Handler(kithttp.NewServer(
@@ -37,21 +85,30 @@ func MakeHandler(messagesService Service, logger log.Logger) http.Handler {
 			options...,
 		))
 
-	return middlewares.Authentication(r)
+	return r
 }
 
 func decodeMessageRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	if err, ok := r.Context().Value(middlewares.InvalidAuthKey).(error); ok && err != nil {
+	userID, err := middlewares.UserIDFromRequest(r)
+	if err != nil {
 		return nil, err
 	}
 
-	userID, ok := r.Context().Value(middlewares.UserIDKey).(string)
-	if !ok {
-		return nil, models.ErrInvalidData{Field: "user_id"}
+	query := r.URL.Query()
+	opts, err := transport.ParseMessageListOptions(query)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := models.ParseMessageFormat(query.Get("format"))
+	if err != nil {
+		return nil, err
 	}
 
 	return getMessagesRequest{
-		UserID: userID,
+		UserID:  userID,
+		Options: opts,
+		Format:  format,
 	}, nil
 }
 
@@ -64,15 +121,11 @@ func encodeMessageResponse(_ context.Context, w http.ResponseWriter, response in
 }
 
 func decodeMessageByIDRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	if err, ok := r.Context().Value(middlewares.InvalidAuthKey).(error); ok && err != nil {
+	userID, err := middlewares.UserIDFromRequest(r)
+	if err != nil {
 		return nil, err
 	}
 
-	userID, ok := r.Context().Value(middlewares.UserIDKey).(string)
-	if !ok {
-		return nil, models.ErrInvalidData{Field: "user_id"}
-	}
-
 	params := mux.Vars(r)
 
 	messageID := params["message_id"]
@@ -80,12 +133,96 @@ func decodeMessageByIDRequest(_ context.Context, r *http.Request) (interface{},
 		return nil, models.ErrInvalidData{Field: "message_id"}
 	}
 
+	format, err := models.ParseMessageFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		return nil, err
+	}
+
 	return getMessageByIDRequest{
 		UserID:    userID,
 		MessageID: messageID,
+		Format:    format,
+	}, nil
+}
+
+func decodeSendMessageRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	userID, err := middlewares.UserIDFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var message models.OutgoingMessage
+	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+		return nil, models.ErrInvalidData{Field: "message"}
+	}
+
+	return sendMessageRequest{
+		UserID:  userID,
+		Message: &message,
+	}, nil
+}
+
+func decodeRespondMessageRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	request, err := decodeSendMessageRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	req := request.(sendMessageRequest)
+
+	messageID := mux.Vars(r)["message_id"]
+	if messageID == "" {
+		return nil, models.ErrInvalidData{Field: "message_id"}
+	}
+
+	return respondMessageRequest{
+		UserID:    req.UserID,
+		MessageID: messageID,
+		Message:   req.Message,
+	}, nil
+}
+
+func decodeAttachmentRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	userID, err := middlewares.UserIDFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	params := mux.Vars(r)
+
+	messageID := params["message_id"]
+	if messageID == "" {
+		return nil, models.ErrInvalidData{Field: "message_id"}
+	}
+
+	attachmentID := params["attachment_id"]
+	if attachmentID == "" {
+		return nil, models.ErrInvalidData{Field: "attachment_id"}
+	}
+
+	return getAttachmentRequest{
+		UserID:       userID,
+		MessageID:    messageID,
+		AttachmentID: attachmentID,
 	}, nil
 }
 
+// encodeAttachmentResponse streams the decoded attachment bytes instead of encoding a json body.
+func encodeAttachmentResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	if e, ok := response.(errorer); ok && e.error() != nil {
+		return e.error()
+	}
+
+	resp := response.(getAttachmentResponse)
+	attachment := resp.Attachment
+
+	w.Header().Set("Content-Type", attachment.MimeType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	_, err := io.Copy(w, attachment.Content)
+	return err
+}
+
 type errorer interface {
 	error() error
 }
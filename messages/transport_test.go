@@ -0,0 +1,93 @@
+package messages
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/middlewares"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeMessageRequest(t *testing.T) {
+	testcases := []struct {
+		name           string
+		url            string
+		expectedOpts   google.MessageListOptions
+		expectedFormat models.MessageFormat
+		expectedErr    error
+	}{
+		{
+			name: "success - query parameters are decoded into list options.",
+			url:  "/messages/?q=is:unread&label_ids=INBOX,CATEGORY_PERSONAL&label_ids=UNREAD&page_size=20&page_token=abc&include_spam_trash=true",
+			expectedOpts: google.MessageListOptions{
+				MaxResults:       20,
+				PageToken:        "abc",
+				Query:            "is:unread",
+				LabelIDs:         []string{"INBOX", "CATEGORY_PERSONAL", "UNREAD"},
+				IncludeSpamTrash: true,
+			},
+			expectedFormat: models.MessageFormatFull,
+		},
+		{
+			name:           "success - no query parameters.",
+			url:            "/messages/",
+			expectedFormat: models.MessageFormatFull,
+		},
+		{
+			name:           "success - metadata format.",
+			url:            "/messages/?format=metadata",
+			expectedFormat: models.MessageFormatMetadata,
+		},
+		{
+			name:        "failure - unknown format.",
+			url:         "/messages/?format=raw",
+			expectedErr: models.ErrInvalidData{Field: "format"},
+		},
+		{
+			name:        "failure - page size is not a number.",
+			url:         "/messages/?page_size=ten",
+			expectedErr: models.ErrInvalidData{Field: "page_size"},
+		},
+		{
+			name:        "failure - include spam trash is not a boolean.",
+			url:         "/messages/?include_spam_trash=maybe",
+			expectedErr: models.ErrInvalidData{Field: "include_spam_trash"},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.url, nil)
+			req = req.WithContext(middlewares.WithPrincipal(req.Context(), &middlewares.Principal{UserID: "1"}))
+			request, err := decodeMessageRequest(context.Background(), req)
+			assert.Equal(t, test.expectedErr, err)
+			if err == nil {
+				assert.Equal(t, getMessagesRequest{UserID: "1", Options: test.expectedOpts, Format: test.expectedFormat}, request)
+			}
+		})
+	}
+}
+
+func TestEncodeAttachmentResponse(t *testing.T) {
+	t.Run("success - attachment bytes are streamed with their headers.", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		err := encodeAttachmentResponse(context.Background(), w, getAttachmentResponse{
+			Attachment: &models.AttachmentContent{
+				MessageAttachment: models.MessageAttachment{
+					Filename: "reseña.pdf",
+					MimeType: "application/pdf",
+				},
+				Content: strings.NewReader("%PDF-1.4"),
+			},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+		assert.Equal(t, "attachment; filename*=utf-8''rese%C3%B1a.pdf", w.Header().Get("Content-Disposition"))
+		assert.Equal(t, "%PDF-1.4", w.Body.String())
+	})
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigration(upAuthUsersTokenExpiration, downAuthUsersTokenExpiration)
+}
+
+// upAuthUsersTokenExpiration keeps the time of the token expiration, a DATE column truncates it to midnight.
+func upAuthUsersTokenExpiration(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		ALTER TABLE auth_users
+		ALTER COLUMN token_expiration TYPE TIMESTAMPTZ;
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func downAuthUsersTokenExpiration(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		ALTER TABLE IF EXISTS auth_users
+		ALTER COLUMN token_expiration TYPE DATE;
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
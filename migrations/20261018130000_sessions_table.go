@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigration(upSessionsTable, downSessionsTable)
+}
+
+// upSessionsTable keeps one row per refresh token, only the sha256 hash of the refresh token is stored.
+func upSessionsTable(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS sessions(
+			id UUID PRIMARY KEY,
+			family_id UUID NOT NULL,
+			google_id VARCHAR(50) NOT NULL REFERENCES users (google_id),
+			token_hash VARCHAR(64) NOT NULL UNIQUE,
+			expires_at TIMESTAMPTZ NOT NULL,
+			rotated_at TIMESTAMPTZ,
+			revoked_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);
+		CREATE INDEX IF NOT EXISTS sessions_family_id_idx ON sessions (family_id);
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func downSessionsTable(tx *sql.Tx) error {
+	_, err := tx.Exec("DROP TABLE IF EXISTS sessions;")
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+package composer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+)
+
+const (
+	// lineLength is the maximum length of a base64 encoded line, see RFC 2045 section 6.8.
+	lineLength = 76
+	charset    = "utf-8"
+)
+
+/*
+Compose builds an RFC 5322 message out of an outgoing message. The resulting MIME tree is:
+
+	multipart/mixed (only when there are attachments)
+		multipart/alternative (only when there are both text and html bodies)
+			text/plain
+			text/html
+		attachments...
+*/
+func Compose(msg *models.OutgoingMessage) ([]byte, error) {
+	if err := Validate(msg); err != nil {
+		return nil, err
+	}
+	return compose(msg)
+}
+
+// ComposeDraft builds the message like Compose but accepts messages without recipients since drafts are saved while they are written.
+func ComposeDraft(msg *models.OutgoingMessage) ([]byte, error) {
+	if err := ValidateDraft(msg); err != nil {
+		return nil, err
+	}
+	return compose(msg)
+}
+
+func compose(msg *models.OutgoingMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	header := textproto.MIMEHeader{}
+	header.Set("MIME-Version", "1.0")
+	header.Set("Date", time.Now().Format(time.RFC1123Z))
+	setAddressHeader(header, "To", msg.To)
+	setAddressHeader(header, "Cc", msg.Cc)
+	setAddressHeader(header, "Bcc", msg.Bcc)
+	header.Set("Subject", mime.QEncoding.Encode(charset, msg.Subject))
+	if msg.InReplyTo != "" {
+		header.Set("In-Reply-To", msg.InReplyTo)
+	}
+	if msg.References != "" {
+		header.Set("References", msg.References)
+	}
+
+	if len(msg.Attachments) == 0 {
+		bodyHeader, body, err := composeBody(msg)
+		if err != nil {
+			return nil, err
+		}
+		for key, values := range bodyHeader {
+			header[key] = values
+		}
+		writeHeader(&buf, header)
+		buf.Write(body)
+		return buf.Bytes(), nil
+	}
+
+	mixed := multipart.NewWriter(&buf)
+	header.Set("Content-Type", fmt.Sprintf("multipart/mixed; boundary=%q", mixed.Boundary()))
+	writeHeader(&buf, header)
+
+	if msg.Text != "" || msg.HTML != "" {
+		bodyHeader, body, err := composeBody(msg)
+		if err != nil {
+			return nil, err
+		}
+		part, err := mixed.CreatePart(bodyHeader)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write(body); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, attachment := range msg.Attachments {
+		if err := writeAttachment(mixed, attachment); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Raw composes the message and encodes it as the base64url string expected by gmail.Message.Raw.
+func Raw(msg *models.OutgoingMessage) (string, error) {
+	message, err := Compose(msg)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(message), nil
+}
+
+// RawDraft composes the draft message and encodes it as the base64url string expected by gmail.Message.Raw.
+func RawDraft(msg *models.OutgoingMessage) (string, error) {
+	message, err := ComposeDraft(msg)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(message), nil
+}
+
+// Validate checks that the outgoing message has at least one valid recipient and valid attachments.
+func Validate(msg *models.OutgoingMessage) error {
+	if err := ValidateDraft(msg); err != nil {
+		return err
+	}
+
+	if len(msg.To)+len(msg.Cc)+len(msg.Bcc) == 0 {
+		return models.ErrInvalidData{Field: "to"}
+	}
+
+	return nil
+}
+
+// ValidateDraft checks that the recipients and attachments of the message are valid, recipients are optional.
+func ValidateDraft(msg *models.OutgoingMessage) error {
+	if msg == nil {
+		return models.ErrInvalidData{Field: "message"}
+	}
+
+	for field, addresses := range map[string][]models.Address{"to": msg.To, "cc": msg.Cc, "bcc": msg.Bcc} {
+		for _, address := range addresses {
+			if _, err := mail.ParseAddress(address.Email); err != nil {
+				return models.ErrInvalidData{Field: field}
+			}
+		}
+	}
+
+	for _, attachment := range msg.Attachments {
+		if attachment.Filename == "" {
+			return models.ErrInvalidData{Field: "attachments.filename"}
+		}
+	}
+
+	return nil
+}
+
+// composeBody returns the content header and the encoded text and html bodies, either as a single part or as multipart/alternative.
+func composeBody(msg *models.OutgoingMessage) (textproto.MIMEHeader, []byte, error) {
+	var body bytes.Buffer
+
+	switch {
+	case msg.Text != "" && msg.HTML != "":
+		alternative := multipart.NewWriter(&body)
+		for _, text := range []struct{ mimeType, content string }{
+			{"text/plain", msg.Text},
+			{"text/html", msg.HTML},
+		} {
+			part, err := alternative.CreatePart(textHeader(text.mimeType))
+			if err != nil {
+				return nil, nil, err
+			}
+			if err := writeQuotedPrintable(part, text.content); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if err := alternative.Close(); err != nil {
+			return nil, nil, err
+		}
+
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", alternative.Boundary()))
+		return header, body.Bytes(), nil
+	case msg.HTML != "":
+		if err := writeQuotedPrintable(&body, msg.HTML); err != nil {
+			return nil, nil, err
+		}
+		return textHeader("text/html"), body.Bytes(), nil
+	default:
+		if err := writeQuotedPrintable(&body, msg.Text); err != nil {
+			return nil, nil, err
+		}
+		return textHeader("text/plain"), body.Bytes(), nil
+	}
+}
+
+func writeAttachment(w *multipart.Writer, attachment models.Attachment) error {
+	mimeType := attachment.MimeType
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(filepath.Ext(attachment.Filename))
+	}
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", mime.FormatMediaType(mimeType, map[string]string{"name": attachment.Filename}))
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	header.Set("Content-Transfer-Encoding", "base64")
+
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+	for len(encoded) > lineLength {
+		if _, err := io.WriteString(part, encoded[:lineLength]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[lineLength:]
+	}
+	_, err = io.WriteString(part, encoded+"\r\n")
+	return err
+}
+
+func writeQuotedPrintable(w io.Writer, content string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := io.WriteString(qp, content); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func textHeader(mimeType string) textproto.MIMEHeader {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", mime.FormatMediaType(mimeType, map[string]string{"charset": charset}))
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	return header
+}
+
+// setAddressHeader formats the addresses following RFC 5322, display names are encoded with RFC 2047 when needed.
+func setAddressHeader(header textproto.MIMEHeader, key string, addresses []models.Address) {
+	if len(addresses) == 0 {
+		return
+	}
+
+	formatted := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		formatted = append(formatted, (&mail.Address{Name: address.Name, Address: address.Email}).String())
+	}
+	header.Set(key, strings.Join(formatted, ", "))
+}
+
+// writeHeader writes the header fields in a stable order followed by the blank line that ends the header section.
+func writeHeader(w io.Writer, header textproto.MIMEHeader) {
+	for _, key := range headerOrder(header) {
+		for _, value := range header[key] {
+			fmt.Fprintf(w, "%s: %s\r\n", key, value)
+		}
+	}
+	fmt.Fprint(w, "\r\n")
+}
+
+func headerOrder(header textproto.MIMEHeader) []string {
+	order := []string{"Mime-Version", "Date", "To", "Cc", "Bcc", "Subject", "In-Reply-To", "References", "Content-Type", "Content-Transfer-Encoding"}
+	keys := make([]string, 0, len(header))
+	seen := make(map[string]bool)
+	for _, key := range order {
+		if _, ok := header[key]; ok {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+	extra := make([]string, 0, len(header))
+	for key := range header {
+		if !seen[key] {
+			extra = append(extra, key)
+		}
+	}
+	sort.Strings(extra)
+	return append(keys, extra...)
+}
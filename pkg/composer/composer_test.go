@@ -0,0 +1,245 @@
+package composer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func parseMessage(t *testing.T, raw []byte) *mail.Message {
+	message, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("cannot parse composed message: %v", err)
+	}
+	return message
+}
+
+func readQuotedPrintable(t *testing.T, r io.Reader) string {
+	content, err := io.ReadAll(quotedprintable.NewReader(r))
+	if err != nil {
+		t.Fatalf("cannot read quoted-printable body: %v", err)
+	}
+	return string(content)
+}
+
+func TestComposeHeaders(t *testing.T) {
+	t.Run("success - non ascii subject and names are encoded following RFC 2047.", func(t *testing.T) {
+		raw, err := Compose(&models.OutgoingMessage{
+			To:      []models.Address{{Name: "José Pérez", Email: "jose@example.com"}},
+			Cc:      []models.Address{{Email: "cc@example.com"}},
+			Bcc:     []models.Address{{Name: "Hidden", Email: "bcc@example.com"}},
+			Subject: "¡Hola! 日本語の件名",
+			Text:    "hello",
+		})
+		assert.Nil(t, err)
+
+		message := parseMessage(t, raw)
+		assert.True(t, strings.HasPrefix(message.Header.Get("Subject"), "=?utf-8?q?"))
+
+		subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+		assert.Nil(t, err)
+		assert.Equal(t, "¡Hola! 日本語の件名", subject)
+
+		to, err := message.Header.AddressList("To")
+		assert.Nil(t, err)
+		assert.Equal(t, []*mail.Address{{Name: "José Pérez", Address: "jose@example.com"}}, to)
+
+		cc, err := message.Header.AddressList("Cc")
+		assert.Nil(t, err)
+		assert.Equal(t, "cc@example.com", cc[0].Address)
+
+		bcc, err := message.Header.AddressList("Bcc")
+		assert.Nil(t, err)
+		assert.Equal(t, "bcc@example.com", bcc[0].Address)
+
+		assert.Equal(t, "1.0", message.Header.Get("MIME-Version"))
+		assert.NotEmpty(t, message.Header.Get("Date"))
+	})
+
+	t.Run("success - subject line breaks cannot inject headers.", func(t *testing.T) {
+		raw, err := Compose(&models.OutgoingMessage{
+			To:      []models.Address{{Email: "jose@example.com"}},
+			Subject: "hello\r\nBcc: attacker@example.com",
+			Text:    "hello",
+		})
+		assert.Nil(t, err)
+
+		message := parseMessage(t, raw)
+		assert.Empty(t, message.Header.Get("Bcc"))
+	})
+}
+
+func TestComposeBody(t *testing.T) {
+	t.Run("success - text only message is a single text/plain part.", func(t *testing.T) {
+		raw, err := Compose(&models.OutgoingMessage{
+			To:   []models.Address{{Email: "jose@example.com"}},
+			Text: "plain text with ñ",
+		})
+		assert.Nil(t, err)
+
+		message := parseMessage(t, raw)
+		mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+		assert.Nil(t, err)
+		assert.Equal(t, "text/plain", mediaType)
+		assert.Equal(t, "utf-8", params["charset"])
+		assert.Equal(t, "plain text with ñ", readQuotedPrintable(t, message.Body))
+	})
+
+	t.Run("success - text and html bodies are sent as multipart/alternative.", func(t *testing.T) {
+		raw, err := Compose(&models.OutgoingMessage{
+			To:   []models.Address{{Email: "jose@example.com"}},
+			Text: "plain",
+			HTML: "<p>html</p>",
+		})
+		assert.Nil(t, err)
+
+		message := parseMessage(t, raw)
+		mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+		assert.Nil(t, err)
+		assert.Equal(t, "multipart/alternative", mediaType)
+
+		reader := multipart.NewReader(message.Body, params["boundary"])
+		part, err := reader.NextRawPart()
+		assert.Nil(t, err)
+		assert.Equal(t, "text/plain; charset=utf-8", part.Header.Get("Content-Type"))
+		assert.Equal(t, "plain", readQuotedPrintable(t, part))
+
+		part, err = reader.NextRawPart()
+		assert.Nil(t, err)
+		assert.Equal(t, "text/html; charset=utf-8", part.Header.Get("Content-Type"))
+		assert.Equal(t, "<p>html</p>", readQuotedPrintable(t, part))
+
+		_, err = reader.NextRawPart()
+		assert.Equal(t, io.EOF, err)
+	})
+
+	t.Run("success - attachments are sent inside multipart/mixed.", func(t *testing.T) {
+		data := bytes.Repeat([]byte("attachment data "), 20)
+		raw, err := Compose(&models.OutgoingMessage{
+			To:   []models.Address{{Email: "jose@example.com"}},
+			Text: "plain",
+			HTML: "<p>html</p>",
+			Attachments: []models.Attachment{
+				{Filename: "report.pdf", Data: data},
+				{Filename: "reseña.txt", MimeType: "text/plain", Data: []byte("hola")},
+			},
+		})
+		assert.Nil(t, err)
+
+		message := parseMessage(t, raw)
+		mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+		assert.Nil(t, err)
+		assert.Equal(t, "multipart/mixed", mediaType)
+
+		reader := multipart.NewReader(message.Body, params["boundary"])
+		part, err := reader.NextRawPart()
+		assert.Nil(t, err)
+		assert.True(t, strings.HasPrefix(part.Header.Get("Content-Type"), "multipart/alternative"))
+
+		part, err = reader.NextRawPart()
+		assert.Nil(t, err)
+		assert.Equal(t, "application/pdf", strings.Split(part.Header.Get("Content-Type"), ";")[0])
+		assert.Equal(t, "report.pdf", part.FileName())
+		content, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+		assert.Nil(t, err)
+		assert.Equal(t, data, content)
+
+		part, err = reader.NextRawPart()
+		assert.Nil(t, err)
+		assert.Equal(t, "reseña.txt", part.FileName())
+	})
+}
+
+func TestRaw(t *testing.T) {
+	t.Run("success - raw message is base64url encoded.", func(t *testing.T) {
+		raw, err := Raw(&models.OutgoingMessage{
+			To:   []models.Address{{Email: "jose@example.com"}},
+			Text: "hello???>>>",
+		})
+		assert.Nil(t, err)
+
+		decoded, err := base64.URLEncoding.DecodeString(raw)
+		assert.Nil(t, err)
+		assert.Contains(t, string(decoded), "To: <jose@example.com>")
+	})
+}
+
+func TestValidate(t *testing.T) {
+	testcases := []struct {
+		name        string
+		message     *models.OutgoingMessage
+		expectedErr error
+	}{
+		{
+			name: "success - message with a single bcc recipient.",
+			message: &models.OutgoingMessage{
+				Bcc: []models.Address{{Email: "bcc@example.com"}},
+			},
+		},
+		{
+			name:        "failure - message without recipients.",
+			message:     &models.OutgoingMessage{Subject: "hello"},
+			expectedErr: models.ErrInvalidData{Field: "to"},
+		},
+		{
+			name: "failure - recipient address is invalid.",
+			message: &models.OutgoingMessage{
+				To: []models.Address{{Email: "not an email"}},
+			},
+			expectedErr: models.ErrInvalidData{Field: "to"},
+		},
+		{
+			name: "failure - attachment without filename.",
+			message: &models.OutgoingMessage{
+				To:          []models.Address{{Email: "jose@example.com"}},
+				Attachments: []models.Attachment{{Data: []byte("data")}},
+			},
+			expectedErr: models.ErrInvalidData{Field: "attachments.filename"},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expectedErr, Validate(test.message))
+		})
+	}
+}
+
+func TestValidateDraft(t *testing.T) {
+	testcases := []struct {
+		name        string
+		message     *models.OutgoingMessage
+		expectedErr error
+	}{
+		{
+			name:    "success - draft without recipients.",
+			message: &models.OutgoingMessage{Subject: "hello"},
+		},
+		{
+			name: "failure - recipient address is invalid.",
+			message: &models.OutgoingMessage{
+				Cc: []models.Address{{Email: "not an email"}},
+			},
+			expectedErr: models.ErrInvalidData{Field: "cc"},
+		},
+		{
+			name:        "failure - missing message.",
+			expectedErr: models.ErrInvalidData{Field: "message"},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expectedErr, ValidateDraft(test.message))
+		})
+	}
+}
@@ -0,0 +1,181 @@
+// Package envelope encrypts the secrets stored at rest with envelope encryption: every value is sealed
+// with its own data key, and the data key is sealed with a key encryption key (KEK) identified by its id.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Prefix marks the values sealed by a Cipher, the values without it are considered plaintext.
+const Prefix = "enc:v1:"
+
+const keySize = 32
+
+var encoding = base64.RawURLEncoding
+
+// Cipher seals and opens values with a set of KEKs, the current KEK seals the new values and
+// every KEK opens the values it sealed, which allows rotating the KEK without downtime.
+type Cipher struct {
+	currentID string
+	keks      map[string][]byte
+}
+
+// New builds a Cipher that seals with the KEK currentID, every KEK must be 32 bytes long (AES-256).
+func New(currentID string, keks map[string][]byte) (*Cipher, error) {
+	c := &Cipher{
+		currentID: currentID,
+		keks:      make(map[string][]byte, len(keks)),
+	}
+	for id, kek := range keks {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid key encryption key id %q", id)
+		}
+		if len(kek) != keySize {
+			return nil, fmt.Errorf("key encryption key %s must be %d bytes long, got %d", id, keySize, len(kek))
+		}
+		c.keks[id] = kek
+	}
+
+	if _, ok := c.keks[currentID]; !ok {
+		return nil, fmt.Errorf("unknown current key encryption key %q", currentID)
+	}
+
+	return c, nil
+}
+
+// CurrentID returns the id of the KEK that seals the new values.
+func (c *Cipher) CurrentID() string {
+	return c.currentID
+}
+
+/*
+Encrypt seals the plaintext bound to the additional data, e.g. the id of the row the value belongs to,
+so a sealed value copied into another row cannot be opened. The result has the form:
+
+	enc:v1:<kek id>:<sealed data key>:<sealed plaintext>
+
+The empty string is returned as it is, there is nothing to protect.
+*/
+func (c *Cipher) Encrypt(plaintext, additionalData string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	dataKey := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+
+	sealedKey, err := seal(c.keks[c.currentID], dataKey, []byte(c.currentID))
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := seal(dataKey, []byte(plaintext), []byte(additionalData))
+	if err != nil {
+		return "", err
+	}
+
+	return Prefix + c.currentID + ":" + encoding.EncodeToString(sealedKey) + ":" + encoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value sealed by Encrypt with the same additional data. The values without the Prefix
+// are returned as they are, they were stored before the encryption was enabled.
+func (c *Cipher) Decrypt(value, additionalData string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, Prefix), ":")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+
+	kek, ok := c.keks[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("unknown key encryption key %q", parts[0])
+	}
+
+	sealedKey, err := encoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted data key: %w", err)
+	}
+
+	sealed, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+
+	dataKey, err := open(kek, sealedKey, []byte(parts[0]))
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := open(dataKey, sealed, []byte(additionalData))
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// NeedsRotation reports whether the value is plaintext or sealed with a KEK other than the current one.
+func (c *Cipher) NeedsRotation(value string) bool {
+	if value == "" {
+		return false
+	}
+	return !strings.HasPrefix(value, Prefix+c.currentID+":")
+}
+
+// IsEncrypted reports whether the value was sealed by a Cipher.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// seal encrypts the plaintext with AES-GCM, the random nonce is prepended to the result.
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(key, sealed, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("malformed encrypted value")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt the value: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
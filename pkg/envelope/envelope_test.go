@@ -0,0 +1,123 @@
+package envelope
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCipher(t *testing.T, currentID string) *Cipher {
+	c, err := New(currentID, map[string][]byte{
+		"2026-04": bytes.Repeat([]byte{4}, keySize),
+		"2026-10": bytes.Repeat([]byte{10}, keySize),
+	})
+	if err != nil {
+		t.Fatalf("cannot build the cipher: %v", err)
+	}
+	return c
+}
+
+func TestNew(t *testing.T) {
+	testcases := []struct {
+		name        string
+		currentID   string
+		keks        map[string][]byte
+		expectedErr bool
+	}{
+		{
+			name:      "success - valid keys.",
+			currentID: "1",
+			keks:      map[string][]byte{"1": make([]byte, keySize)},
+		},
+		{
+			name:        "failure - short key.",
+			currentID:   "1",
+			keks:        map[string][]byte{"1": make([]byte, 16)},
+			expectedErr: true,
+		},
+		{
+			name:        "failure - unknown current key.",
+			currentID:   "2",
+			keks:        map[string][]byte{"1": make([]byte, keySize)},
+			expectedErr: true,
+		},
+		{
+			name:        "failure - key id with separator.",
+			currentID:   "a:b",
+			keks:        map[string][]byte{"a:b": make([]byte, keySize)},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := New(test.currentID, test.keks)
+			assert.Equal(t, test.expectedErr, err != nil)
+		})
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	c := newTestCipher(t, "2026-10")
+
+	encrypted, err := c.Encrypt("ya29.access-token", "user-1")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, Prefix+"2026-10:"))
+	assert.NotContains(t, encrypted, "ya29.access-token")
+
+	again, err := c.Encrypt("ya29.access-token", "user-1")
+	assert.Nil(t, err)
+	assert.NotEqual(t, encrypted, again)
+
+	plaintext, err := c.Decrypt(encrypted, "user-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "ya29.access-token", plaintext)
+
+	_, err = c.Decrypt(encrypted, "user-2")
+	assert.NotNil(t, err, "a value moved to another user must not be decrypted")
+
+	tampered := encrypted[:len(encrypted)-2] + "AA"
+	_, err = c.Decrypt(tampered, "user-1")
+	assert.NotNil(t, err)
+
+	_, err = c.Decrypt(Prefix+"2026-10:only-two", "user-1")
+	assert.NotNil(t, err)
+
+	empty, err := c.Encrypt("", "user-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "", empty)
+}
+
+func TestDecryptPlaintext(t *testing.T) {
+	c := newTestCipher(t, "2026-10")
+
+	plaintext, err := c.Decrypt("1//refresh-token", "user-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "1//refresh-token", plaintext)
+	assert.True(t, c.NeedsRotation("1//refresh-token"))
+	assert.False(t, c.NeedsRotation(""))
+}
+
+func TestRotation(t *testing.T) {
+	former := newTestCipher(t, "2026-04")
+	current := newTestCipher(t, "2026-10")
+
+	encrypted, err := former.Encrypt("ya29.access-token", "user-1")
+	assert.Nil(t, err)
+	assert.True(t, current.NeedsRotation(encrypted))
+
+	plaintext, err := current.Decrypt(encrypted, "user-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "ya29.access-token", plaintext)
+
+	rotated, err := current.Encrypt(plaintext, "user-1")
+	assert.Nil(t, err)
+	assert.False(t, current.NeedsRotation(rotated))
+
+	withoutFormer, err := New("2026-10", map[string][]byte{"2026-10": bytes.Repeat([]byte{10}, keySize)})
+	assert.Nil(t, err)
+	_, err = withoutFormer.Decrypt(encrypted, "user-1")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,46 @@
+package envelope
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/orlandorode97/mailx-google-service/pkg/keyconfig"
+)
+
+/*
+Load builds the cipher from the configuration, get is usually viper.GetString.
+The configuration is:
+  - TOKEN_KEKS: comma separated list of KEK ids, e.g. `2026-10,2026-04`.
+  - TOKEN_KEK_CURRENT: id of the KEK that seals new values, it defaults to the first KEK of TOKEN_KEKS.
+  - TOKEN_KEK_<ID>_FILE: path of the file with the base64 encoded 32 bytes KEK, or TOKEN_KEK_<ID> with its content.
+
+See keyconfig for how the ids are listed and named.
+*/
+func Load(get func(string) string) (*Cipher, error) {
+	ids := keyconfig.IDs(get("TOKEN_KEKS"))
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("TOKEN_KEKS is not configured")
+	}
+
+	keks := make(map[string][]byte, len(ids))
+	for _, id := range ids {
+		material, err := keyconfig.Material(get, "TOKEN_KEK_", id)
+		if err != nil {
+			return nil, fmt.Errorf("reading key encryption key %s: %w", id, err)
+		}
+
+		kek, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(material)))
+		if err != nil {
+			return nil, fmt.Errorf("decoding key encryption key %s: %w", id, err)
+		}
+		keks[id] = kek
+	}
+
+	currentID := get("TOKEN_KEK_CURRENT")
+	if currentID == "" {
+		currentID = ids[0]
+	}
+
+	return New(currentID, keks)
+}
@@ -0,0 +1,77 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	kek := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, keySize))
+	path := filepath.Join(t.TempDir(), "kek")
+	if err := os.WriteFile(path, []byte(kek+"\n"), 0600); err != nil {
+		t.Fatalf("cannot write the kek file: %v", err)
+	}
+
+	testcases := []struct {
+		name              string
+		env               map[string]string
+		expectedCurrentID string
+		expectedErr       bool
+	}{
+		{
+			name: "success - first key is the current one.",
+			env: map[string]string{
+				"TOKEN_KEKS":             "2026-10, 2026-04",
+				"TOKEN_KEK_2026_10":      kek,
+				"TOKEN_KEK_2026_04_FILE": path,
+			},
+			expectedCurrentID: "2026-10",
+		},
+		{
+			name: "success - configured current key.",
+			env: map[string]string{
+				"TOKEN_KEKS":        "2026-10,2026-04",
+				"TOKEN_KEK_CURRENT": "2026-04",
+				"TOKEN_KEK_2026_10": kek,
+				"TOKEN_KEK_2026_04": kek,
+			},
+			expectedCurrentID: "2026-04",
+		},
+		{
+			name:        "failure - missing configuration.",
+			env:         map[string]string{},
+			expectedErr: true,
+		},
+		{
+			name: "failure - invalid base64 key.",
+			env: map[string]string{
+				"TOKEN_KEKS":        "2026-10",
+				"TOKEN_KEK_2026_10": "not base64!",
+			},
+			expectedErr: true,
+		},
+		{
+			name: "failure - missing key file.",
+			env: map[string]string{
+				"TOKEN_KEKS":             "2026-10",
+				"TOKEN_KEK_2026_10_FILE": filepath.Join(t.TempDir(), "missing"),
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := Load(func(key string) string { return test.env[key] })
+			assert.Equal(t, test.expectedErr, err != nil)
+			if err == nil {
+				assert.Equal(t, test.expectedCurrentID, c.CurrentID())
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package extractor
+
+import (
+	"encoding/base64"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
+	"google.golang.org/api/gmail/v1"
+)
+
+// Body holds the decoded html and text bodies of a message, both transcoded to UTF-8.
+type Body struct {
+	HTML string
+	Text string
+}
+
+/*
+Extract walks the MIME tree of a gmail message and returns the first text/html and text/plain bodies.
+It handles:
+  - single part messages where the body lives in Payload.Body.Data.
+  - nested multipart/alternative parts inside multipart/mixed or multipart/related.
+  - bodies declared with a charset other than UTF-8 such as ISO-8859-1, Windows-1252 or Shift_JIS.
+
+Attached files and attached messages (message/rfc822) are skipped.
+*/
+func Extract(payload *gmail.MessagePart) (*Body, error) {
+	body := &Body{}
+	if err := extract(payload, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func extract(part *gmail.MessagePart, body *Body) error {
+	if part == nil || IsAttachment(part) || part.MimeType == "message/rfc822" {
+		return nil
+	}
+
+	if part.Body != nil && part.Body.Data != "" {
+		switch {
+		case part.MimeType == "text/html" && body.HTML == "":
+			content, err := decodePart(part)
+			if err != nil {
+				return err
+			}
+			body.HTML = content
+		case part.MimeType == "text/plain" && body.Text == "":
+			content, err := decodePart(part)
+			if err != nil {
+				return err
+			}
+			body.Text = content
+		}
+	}
+
+	for _, p := range part.Parts {
+		if err := extract(p, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Walk visits every part of the MIME tree in depth-first order.
+func Walk(part *gmail.MessagePart, visit func(*gmail.MessagePart) error) error {
+	if part == nil {
+		return nil
+	}
+
+	if err := visit(part); err != nil {
+		return err
+	}
+
+	for _, p := range part.Parts {
+		if err := Walk(p, visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsAttachment reports whether a part is a file attached to the message rather than a body.
+func IsAttachment(part *gmail.MessagePart) bool {
+	if part.Filename != "" && part.Body != nil {
+		return true
+	}
+
+	disposition, _, err := mime.ParseMediaType(Header(part, "Content-Disposition"))
+	return err == nil && disposition == "attachment"
+}
+
+// Header returns the first value of a part header, header names are case insensitive.
+func Header(part *gmail.MessagePart, name string) string {
+	for _, header := range part.Headers {
+		if strings.EqualFold(header.Name, name) {
+			return header.Value
+		}
+	}
+	return ""
+}
+
+// DecodeData decodes the base64url data returned by the gmail api. Gmail omits the padding in most responses.
+func DecodeData(data string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(data, "="))
+}
+
+func decodePart(part *gmail.MessagePart) (string, error) {
+	data, err := DecodeData(part.Body.Data)
+	if err != nil {
+		return "", err
+	}
+
+	_, params, _ := mime.ParseMediaType(Header(part, "Content-Type"))
+	return ToUTF8(data, params["charset"]), nil
+}
+
+// ToUTF8 transcodes the content from the declared charset. Unknown charsets are kept as they are,
+// replacing the invalid UTF-8 sequences.
+func ToUTF8(content []byte, charset string) string {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return strings.ToValidUTF8(string(content), string(utf8.RuneError))
+	}
+
+	encoding, err := htmlindex.Get(charset)
+	if err != nil {
+		return strings.ToValidUTF8(string(content), string(utf8.RuneError))
+	}
+
+	decoded, err := encoding.NewDecoder().Bytes(content)
+	if err != nil {
+		return strings.ToValidUTF8(string(content), string(utf8.RuneError))
+	}
+	return string(decoded)
+}
@@ -0,0 +1,180 @@
+package extractor
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/japanese"
+	"google.golang.org/api/gmail/v1"
+)
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func textPart(mimeType string, charset string, data []byte) *gmail.MessagePart {
+	part := &gmail.MessagePart{
+		MimeType: mimeType,
+		Body:     &gmail.MessagePartBody{Data: encode(data)},
+	}
+	if charset != "" {
+		part.Headers = []*gmail.MessagePartHeader{
+			{Name: "Content-Type", Value: mimeType + "; charset=\"" + charset + "\""},
+		}
+	}
+	return part
+}
+
+func TestExtract(t *testing.T) {
+	shiftJIS, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte("こんにちは"))
+	if err != nil {
+		t.Fatalf("cannot encode shift_jis: %v", err)
+	}
+
+	testcases := []struct {
+		name         string
+		payload      *gmail.MessagePart
+		expectedBody *Body
+		expectedErr  bool
+	}{
+		{
+			name: "success - single part text/html body.",
+			payload: &gmail.MessagePart{
+				MimeType: "text/html",
+				Body:     &gmail.MessagePartBody{Data: encode([]byte("<p>hello</p>"))},
+			},
+			expectedBody: &Body{HTML: "<p>hello</p>"},
+		},
+		{
+			name: "success - text only message.",
+			payload: &gmail.MessagePart{
+				MimeType: "multipart/mixed",
+				Parts: []*gmail.MessagePart{
+					textPart("text/plain", "utf-8", []byte("hello")),
+				},
+			},
+			expectedBody: &Body{Text: "hello"},
+		},
+		{
+			name: "success - multipart/alternative nested inside multipart/mixed.",
+			payload: &gmail.MessagePart{
+				MimeType: "multipart/mixed",
+				Parts: []*gmail.MessagePart{
+					{
+						MimeType: "multipart/related",
+						Parts: []*gmail.MessagePart{
+							{
+								MimeType: "multipart/alternative",
+								Parts: []*gmail.MessagePart{
+									textPart("text/plain", "", []byte("plain")),
+									textPart("text/html", "", []byte("<p>html</p>")),
+								},
+							},
+						},
+					},
+					{
+						MimeType: "text/plain",
+						Filename: "notes.txt",
+						Body:     &gmail.MessagePartBody{Data: encode([]byte("attached notes"))},
+					},
+				},
+			},
+			expectedBody: &Body{HTML: "<p>html</p>", Text: "plain"},
+		},
+		{
+			name: "success - attached messages are skipped.",
+			payload: &gmail.MessagePart{
+				MimeType: "multipart/mixed",
+				Parts: []*gmail.MessagePart{
+					{
+						MimeType: "message/rfc822",
+						Parts: []*gmail.MessagePart{
+							textPart("text/plain", "", []byte("forwarded")),
+						},
+					},
+					textPart("text/plain", "", []byte("body")),
+				},
+			},
+			expectedBody: &Body{Text: "body"},
+		},
+		{
+			name:         "success - iso-8859-1 body is transcoded to utf-8.",
+			payload:      textPart("text/plain", "ISO-8859-1", []byte{'a', 'c', 'c', 'i', 0xf3, 'n'}),
+			expectedBody: &Body{Text: "acción"},
+		},
+		{
+			name:         "success - windows-1252 body is transcoded to utf-8.",
+			payload:      textPart("text/html", "windows-1252", []byte{0x93, 'h', 'i', 0x94, ' ', 0x80}),
+			expectedBody: &Body{HTML: "“hi” €"},
+		},
+		{
+			name:         "success - shift_jis body is transcoded to utf-8.",
+			payload:      textPart("text/plain", "Shift_JIS", shiftJIS),
+			expectedBody: &Body{Text: "こんにちは"},
+		},
+		{
+			name: "success - padded base64url data.",
+			payload: &gmail.MessagePart{
+				MimeType: "text/plain",
+				Body:     &gmail.MessagePartBody{Data: base64.URLEncoding.EncodeToString([]byte("hi?>"))},
+			},
+			expectedBody: &Body{Text: "hi?>"},
+		},
+		{
+			name: "failure - body is not base64url.",
+			payload: &gmail.MessagePart{
+				MimeType: "text/plain",
+				Body:     &gmail.MessagePartBody{Data: "not base64url!"},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			body, err := Extract(test.payload)
+			if test.expectedErr {
+				assert.NotNil(t, err)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, test.expectedBody, body)
+		})
+	}
+}
+
+func TestIsAttachment(t *testing.T) {
+	testcases := []struct {
+		name     string
+		part     *gmail.MessagePart
+		expected bool
+	}{
+		{
+			name:     "success - part with filename.",
+			part:     &gmail.MessagePart{Filename: "report.pdf", Body: &gmail.MessagePartBody{AttachmentId: "1"}},
+			expected: true,
+		},
+		{
+			name: "success - part with attachment disposition.",
+			part: &gmail.MessagePart{
+				Headers: []*gmail.MessagePartHeader{{Name: "content-disposition", Value: "attachment"}},
+			},
+			expected: true,
+		},
+		{
+			name: "success - inline body.",
+			part: &gmail.MessagePart{
+				MimeType: "text/plain",
+				Headers:  []*gmail.MessagePartHeader{{Name: "Content-Disposition", Value: "inline"}},
+			},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, IsAttachment(test.part))
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package extractor
+
+import (
+	"io"
+	"mime"
+	"net/mail"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// wordDecoder decodes RFC 2047 encoded words in any charset known by the html index, not only UTF-8 and ISO-8859-1.
+var wordDecoder = &mime.WordDecoder{
+	CharsetReader: func(charset string, input io.Reader) (io.Reader, error) {
+		encoding, err := htmlindex.Get(charset)
+		if err != nil {
+			return nil, err
+		}
+		return encoding.NewDecoder().Reader(input), nil
+	},
+}
+
+// DecodeHeader decodes the RFC 2047 encoded words of a header value, e.g. `=?utf-8?q?caf=C3=A9?=`.
+// The value is returned as it is when it cannot be decoded.
+func DecodeHeader(value string) string {
+	decoded, err := wordDecoder.DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+// ParseAddressList parses a list of RFC 5322 addresses decoding the encoded display names.
+func ParseAddressList(value string) ([]*mail.Address, error) {
+	parser := &mail.AddressParser{WordDecoder: wordDecoder}
+	return parser.ParseList(value)
+}
@@ -0,0 +1,58 @@
+package google
+
+import (
+	"context"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+type AttachmentsService struct {
+	s       *gmail.UsersMessagesAttachmentsService
+	retrier *Retrier
+}
+
+func NewAttachmentsService(attachmentsSvc *gmail.UsersMessagesAttachmentsService, retrier *Retrier) *AttachmentsService {
+	return &AttachmentsService{
+		s:       attachmentsSvc,
+		retrier: retrier,
+	}
+}
+
+func (a *AttachmentsService) Get(ctx context.Context, userID string, messageID string, attachmentID string) AttacherClient {
+	getCall := a.s.Get(userID, messageID, attachmentID)
+	r := a.retrier.newRetry(ctx, true)
+	getCall.Context(r.ctx)
+	return &retryingAttacherClient{retry: r, call: getCall}
+}
+
+/*
+ The listed interfaces represents an abstraction of the *gmail.UsersMessagesAttachmentsService and its methods and actioners:
+	Get -> Do() (*gmail.MessagePartBody, error)
+*/
+
+type AttacherClient interface {
+	Do(opts ...googleapi.CallOption) (*gmail.MessagePartBody, error)
+}
+
+type AttachmentGetterCall interface {
+	Get(context.Context, string, string, string) AttacherClient
+}
+
+type Attacher interface {
+	AttachmentGetterCall
+}
+
+type retryingAttacherClient struct {
+	retry
+	call AttacherClient
+}
+
+func (c *retryingAttacherClient) Do(opts ...googleapi.CallOption) (*gmail.MessagePartBody, error) {
+	var resp *gmail.MessagePartBody
+	err := c.do(func() (err error) {
+		resp, err = c.call.Do(opts...)
+		return err
+	})
+	return resp, err
+}
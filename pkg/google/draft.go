@@ -0,0 +1,169 @@
+package google
+
+import (
+	"context"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+type DraftsService struct {
+	s       *gmail.UsersDraftsService
+	retrier *Retrier
+}
+
+func NewDraftsService(draftsSvc *gmail.UsersDraftsService, retrier *Retrier) *DraftsService {
+	return &DraftsService{
+		s:       draftsSvc,
+		retrier: retrier,
+	}
+}
+
+func (d *DraftsService) Create(ctx context.Context, userID string, draft *gmail.Draft) DrafterClientResp {
+	createCall := d.s.Create(userID, draft)
+	r := d.retrier.newRetry(ctx, false)
+	createCall.Context(r.ctx)
+	return &retryingDrafterClientResp{retry: r, call: createCall}
+}
+func (d *DraftsService) Delete(ctx context.Context, userID string, draftID string) DrafterClient {
+	deleteCall := d.s.Delete(userID, draftID)
+	r := d.retrier.newRetry(ctx, true)
+	deleteCall.Context(r.ctx)
+	return &retryingCall{retry: r, call: deleteCall}
+}
+func (d *DraftsService) Get(ctx context.Context, userID string, draftID string) DrafterClientResp {
+	getCall := d.s.Get(userID, draftID)
+	r := d.retrier.newRetry(ctx, true)
+	getCall.Context(r.ctx)
+	return &retryingDrafterClientResp{retry: r, call: getCall}
+}
+
+// List accepts the same options as the messages list call, gmail does not filter drafts by label so LabelIDs are ignored.
+func (d *DraftsService) List(ctx context.Context, userID string, opts MessageListOptions) DrafterClientList {
+	listCall := d.s.List(userID).IncludeSpamTrash(opts.IncludeSpamTrash)
+	if opts.MaxResults > 0 {
+		listCall.MaxResults(opts.MaxResults)
+	}
+	if opts.PageToken != "" {
+		listCall.PageToken(opts.PageToken)
+	}
+	if opts.Query != "" {
+		listCall.Q(opts.Query)
+	}
+	r := d.retrier.newRetry(ctx, true)
+	listCall.Context(r.ctx)
+	return &retryingDrafterClientList{retry: r, call: listCall}
+}
+func (d *DraftsService) Send(ctx context.Context, userID string, draft *gmail.Draft) DrafterClientSend {
+	sendCall := d.s.Send(userID, draft)
+	r := d.retrier.newRetry(ctx, false)
+	sendCall.Context(r.ctx)
+	return &retryingDrafterClientSend{retry: r, call: sendCall}
+}
+func (d *DraftsService) Update(ctx context.Context, userID string, draftID string, draft *gmail.Draft) DrafterClientResp {
+	updateCall := d.s.Update(userID, draftID, draft)
+	r := d.retrier.newRetry(ctx, true)
+	updateCall.Context(r.ctx)
+	return &retryingDrafterClientResp{retry: r, call: updateCall}
+}
+
+/*
+ The listed interfaces represents an abstraction of the *gmail.UsersDraftsService and its methods and actioners:
+	Create -> Do()
+	Delete -> Do()
+	Get -> Do()
+	List -> Do()
+	Send -> Do()
+	Update -> Do()
+*/
+
+type DrafterClient interface {
+	Do(opts ...googleapi.CallOption) error
+}
+
+type DrafterClientResp interface {
+	Do(opts ...googleapi.CallOption) (*gmail.Draft, error)
+}
+
+type DrafterClientList interface {
+	Do(opts ...googleapi.CallOption) (*gmail.ListDraftsResponse, error)
+}
+
+type DrafterClientSend interface {
+	Do(opts ...googleapi.CallOption) (*gmail.Message, error)
+}
+
+type DraftCreatorCall interface {
+	Create(context.Context, string, *gmail.Draft) DrafterClientResp
+}
+
+type DraftDeletorCall interface {
+	Delete(context.Context, string, string) DrafterClient
+}
+
+type DraftGetterCall interface {
+	Get(context.Context, string, string) DrafterClientResp
+}
+
+type DraftListerCall interface {
+	List(context.Context, string, MessageListOptions) DrafterClientList
+}
+
+type DraftSenderCall interface {
+	Send(context.Context, string, *gmail.Draft) DrafterClientSend
+}
+
+type DraftUpdaterCall interface {
+	Update(context.Context, string, string, *gmail.Draft) DrafterClientResp
+}
+
+type Drafter interface {
+	DraftCreatorCall
+	DraftDeletorCall
+	DraftGetterCall
+	DraftListerCall
+	DraftSenderCall
+	DraftUpdaterCall
+}
+
+type retryingDrafterClientResp struct {
+	retry
+	call DrafterClientResp
+}
+
+func (c *retryingDrafterClientResp) Do(opts ...googleapi.CallOption) (*gmail.Draft, error) {
+	var resp *gmail.Draft
+	err := c.do(func() (err error) {
+		resp, err = c.call.Do(opts...)
+		return err
+	})
+	return resp, err
+}
+
+type retryingDrafterClientList struct {
+	retry
+	call DrafterClientList
+}
+
+func (c *retryingDrafterClientList) Do(opts ...googleapi.CallOption) (*gmail.ListDraftsResponse, error) {
+	var resp *gmail.ListDraftsResponse
+	err := c.do(func() (err error) {
+		resp, err = c.call.Do(opts...)
+		return err
+	})
+	return resp, err
+}
+
+type retryingDrafterClientSend struct {
+	retry
+	call DrafterClientSend
+}
+
+func (c *retryingDrafterClientSend) Do(opts ...googleapi.CallOption) (*gmail.Message, error) {
+	var resp *gmail.Message
+	err := c.do(func() (err error) {
+		resp, err = c.call.Do(opts...)
+		return err
+	})
+	return resp, err
+}
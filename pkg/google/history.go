@@ -0,0 +1,79 @@
+package google
+
+import (
+	"context"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+type HistoryService struct {
+	s       *gmail.UsersHistoryService
+	retrier *Retrier
+}
+
+func NewHistoryService(historySvc *gmail.UsersHistoryService, retrier *Retrier) *HistoryService {
+	return &HistoryService{
+		s:       historySvc,
+		retrier: retrier,
+	}
+}
+
+func (h *HistoryService) List(ctx context.Context, userID string, opts HistoryListOptions) HistorianClientList {
+	listCall := h.s.List(userID).StartHistoryId(opts.StartHistoryID)
+	if opts.MaxResults > 0 {
+		listCall.MaxResults(opts.MaxResults)
+	}
+	if opts.PageToken != "" {
+		listCall.PageToken(opts.PageToken)
+	}
+	if opts.LabelID != "" {
+		listCall.LabelId(opts.LabelID)
+	}
+	if len(opts.HistoryTypes) > 0 {
+		listCall.HistoryTypes(opts.HistoryTypes...)
+	}
+	r := h.retrier.newRetry(ctx, true)
+	listCall.Context(r.ctx)
+	return &retryingHistorianClientList{retry: r, call: listCall}
+}
+
+/*
+ The listed interfaces represents an abstraction of the *gmail.UsersHistoryService and its methods and actioners:
+	List -> Do()
+*/
+
+type HistorianClientList interface {
+	Do(opts ...googleapi.CallOption) (*gmail.ListHistoryResponse, error)
+}
+
+// HistoryListOptions holds the parameters of a history list call, StartHistoryID is required by gmail.
+type HistoryListOptions struct {
+	StartHistoryID uint64
+	MaxResults     int64
+	PageToken      string
+	LabelID        string
+	HistoryTypes   []string
+}
+
+type HistoryListerCall interface {
+	List(context.Context, string, HistoryListOptions) HistorianClientList
+}
+
+type Historian interface {
+	HistoryListerCall
+}
+
+type retryingHistorianClientList struct {
+	retry
+	call HistorianClientList
+}
+
+func (c *retryingHistorianClientList) Do(opts ...googleapi.CallOption) (*gmail.ListHistoryResponse, error) {
+	var resp *gmail.ListHistoryResponse
+	err := c.do(func() (err error) {
+		resp, err = c.call.Do(opts...)
+		return err
+	})
+	return resp, err
+}
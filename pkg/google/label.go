@@ -8,51 +8,57 @@ import (
 )
 
 type LabelsService struct {
-	s   *gmail.UsersLabelsService
-	ctx context.Context
+	s       *gmail.UsersLabelsService
+	retrier *Retrier
 }
 
-func NewLabelsService(labelSvc *gmail.UsersLabelsService) *LabelsService {
+func NewLabelsService(labelSvc *gmail.UsersLabelsService, retrier *Retrier) *LabelsService {
 	return &LabelsService{
-		ctx: context.Background(),
-		s:   labelSvc,
+		s:       labelSvc,
+		retrier: retrier,
 	}
 }
 
-func (l *LabelsService) Create(userID string, label *gmail.Label) LabelerClient {
+func (l *LabelsService) Create(ctx context.Context, userID string, label *gmail.Label) LabelerClient {
 	createCall := l.s.Create(userID, label)
-	createCall.Context(l.ctx)
-	return createCall
+	r := l.retrier.newRetry(ctx, false)
+	createCall.Context(r.ctx)
+	return &retryingLabelerClient{retry: r, call: createCall}
 }
 
-func (l *LabelsService) Delete(userID string, labelID string) LabelerClientDelete {
+func (l *LabelsService) Delete(ctx context.Context, userID string, labelID string) LabelerClientDelete {
 	deleteCall := l.s.Delete(userID, labelID)
-	deleteCall.Context(l.ctx)
-	return deleteCall
+	r := l.retrier.newRetry(ctx, true)
+	deleteCall.Context(r.ctx)
+	return &retryingCall{retry: r, call: deleteCall}
 }
 
-func (l *LabelsService) Get(userID string, labelID string) LabelerClient {
+func (l *LabelsService) Get(ctx context.Context, userID string, labelID string) LabelerClient {
 	getCall := l.s.Get(userID, labelID)
-	getCall.Context(l.ctx)
-	return getCall
+	r := l.retrier.newRetry(ctx, true)
+	getCall.Context(r.ctx)
+	return &retryingLabelerClient{retry: r, call: getCall}
 }
 
-func (l *LabelsService) List(userID string) LabelerClientList {
+func (l *LabelsService) List(ctx context.Context, userID string) LabelerClientList {
 	listCall := l.s.List(userID)
-	listCall.Context(l.ctx)
-	return listCall
+	r := l.retrier.newRetry(ctx, true)
+	listCall.Context(r.ctx)
+	return &retryingLabelerClientList{retry: r, call: listCall}
 }
 
-func (l *LabelsService) Patch(userID string, labelID string, label *gmail.Label) LabelerClient {
+func (l *LabelsService) Patch(ctx context.Context, userID string, labelID string, label *gmail.Label) LabelerClient {
 	patchCall := l.s.Patch(userID, labelID, label)
-	patchCall.Context(l.ctx)
-	return patchCall
+	r := l.retrier.newRetry(ctx, true)
+	patchCall.Context(r.ctx)
+	return &retryingLabelerClient{retry: r, call: patchCall}
 }
 
-func (l *LabelsService) Update(userID string, labelID string, label *gmail.Label) LabelerClient {
+func (l *LabelsService) Update(ctx context.Context, userID string, labelID string, label *gmail.Label) LabelerClient {
 	updateCall := l.s.Update(userID, labelID, label)
-	updateCall.Context(l.ctx)
-	return updateCall
+	r := l.retrier.newRetry(ctx, true)
+	updateCall.Context(r.ctx)
+	return &retryingLabelerClient{retry: r, call: updateCall}
 }
 
 /*
@@ -78,27 +84,27 @@ type LabelerClientList interface {
 }
 
 type LabelCreatorCall interface {
-	Create(string, *gmail.Label) LabelerClient
+	Create(context.Context, string, *gmail.Label) LabelerClient
 }
 
 type LabelDeletorCall interface {
-	Delete(string, string) LabelerClientDelete
+	Delete(context.Context, string, string) LabelerClientDelete
 }
 
 type LabelGetterCall interface {
-	Get(string, string) LabelerClient
+	Get(context.Context, string, string) LabelerClient
 }
 
 type LabelListerCall interface {
-	List(string) LabelerClientList
+	List(context.Context, string) LabelerClientList
 }
 
 type LabelPatcherCall interface {
-	Patch(string, string, *gmail.Label) LabelerClient
+	Patch(context.Context, string, string, *gmail.Label) LabelerClient
 }
 
 type LabelUpdaterCall interface {
-	Update(string, string, *gmail.Label) LabelerClient
+	Update(context.Context, string, string, *gmail.Label) LabelerClient
 }
 
 type Labeler interface {
@@ -109,3 +115,31 @@ type Labeler interface {
 	LabelPatcherCall
 	LabelUpdaterCall
 }
+
+type retryingLabelerClient struct {
+	retry
+	call LabelerClient
+}
+
+func (c *retryingLabelerClient) Do(opts ...googleapi.CallOption) (*gmail.Label, error) {
+	var resp *gmail.Label
+	err := c.do(func() (err error) {
+		resp, err = c.call.Do(opts...)
+		return err
+	})
+	return resp, err
+}
+
+type retryingLabelerClientList struct {
+	retry
+	call LabelerClientList
+}
+
+func (c *retryingLabelerClientList) Do(opts ...googleapi.CallOption) (*gmail.ListLabelsResponse, error) {
+	var resp *gmail.ListLabelsResponse
+	err := c.do(func() (err error) {
+		resp, err = c.call.Do(opts...)
+		return err
+	})
+	return resp, err
+}
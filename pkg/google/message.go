@@ -1,52 +1,100 @@
 package google
 
 import (
+	"context"
 	"google.golang.org/api/gmail/v1"
 	"google.golang.org/api/googleapi"
 )
 
 type MessagesService struct {
-	s *gmail.UsersMessagesService
+	s       *gmail.UsersMessagesService
+	retrier *Retrier
 }
 
-func NewMessagesService(messagesSvc *gmail.UsersMessagesService) *MessagesService {
+func NewMessagesService(messagesSvc *gmail.UsersMessagesService, retrier *Retrier) *MessagesService {
 	return &MessagesService{
-		s: messagesSvc,
+		s:       messagesSvc,
+		retrier: retrier,
 	}
 }
 
-func (m *MessagesService) BatchDelete(userID string, req *gmail.BatchDeleteMessagesRequest) MessengerClient {
-	return m.s.BatchDelete(userID, req)
-}
-func (m *MessagesService) BatchModify(userID string, req *gmail.BatchModifyMessagesRequest) MessengerClient {
-	return m.s.BatchModify(userID, req)
-}
-func (m *MessagesService) Delete(userID string, messageID string) MessengerClient {
-	return m.s.Delete(userID, messageID)
-}
-func (m *MessagesService) Get(userID string, messageID string) MessengerClientResp {
-	return m.s.Get(userID, messageID)
-}
-func (m *MessagesService) Import(userID string, message *gmail.Message) MessengerClientResp {
-	return m.s.Import(userID, message)
-}
-func (m *MessagesService) Insert(userID string, message *gmail.Message) MessengerClientResp {
-	return m.s.Insert(userID, message)
-}
-func (m *MessagesService) List(userID string, maxResults int64) MessengerClientList {
-	return m.s.List(userID).MaxResults(maxResults)
-}
-func (m *MessagesService) Modify(userID string, messageID string, req *gmail.ModifyMessageRequest) MessengerClientResp {
-	return m.s.Modify(userID, messageID, req)
-}
-func (m *MessagesService) Send(userID string, message *gmail.Message) MessengerClientResp {
-	return m.s.Send(userID, message)
-}
-func (m *MessagesService) Trash(userID string, messageID string) MessengerClientResp {
-	return m.s.Trash(userID, messageID)
-}
-func (m *MessagesService) Untrash(userID string, messageID string) MessengerClientResp {
-	return m.s.Untrash(userID, messageID)
+func (m *MessagesService) BatchDelete(ctx context.Context, userID string, req *gmail.BatchDeleteMessagesRequest) MessengerClient {
+	batchDeleteCall := m.s.BatchDelete(userID, req)
+	r := m.retrier.newRetry(ctx, true)
+	batchDeleteCall.Context(r.ctx)
+	return &retryingCall{retry: r, call: batchDeleteCall}
+}
+func (m *MessagesService) BatchModify(ctx context.Context, userID string, req *gmail.BatchModifyMessagesRequest) MessengerClient {
+	batchModifyCall := m.s.BatchModify(userID, req)
+	r := m.retrier.newRetry(ctx, true)
+	batchModifyCall.Context(r.ctx)
+	return &retryingCall{retry: r, call: batchModifyCall}
+}
+func (m *MessagesService) Delete(ctx context.Context, userID string, messageID string) MessengerClient {
+	deleteCall := m.s.Delete(userID, messageID)
+	r := m.retrier.newRetry(ctx, true)
+	deleteCall.Context(r.ctx)
+	return &retryingCall{retry: r, call: deleteCall}
+}
+func (m *MessagesService) Get(ctx context.Context, userID string, messageID string) MessengerClientResp {
+	getCall := m.s.Get(userID, messageID)
+	r := m.retrier.newRetry(ctx, true)
+	getCall.Context(r.ctx)
+	return &retryingMessengerClientResp{retry: r, call: getCall}
+}
+func (m *MessagesService) Import(ctx context.Context, userID string, message *gmail.Message) MessengerClientResp {
+	importCall := m.s.Import(userID, message)
+	r := m.retrier.newRetry(ctx, false)
+	importCall.Context(r.ctx)
+	return &retryingMessengerClientResp{retry: r, call: importCall}
+}
+func (m *MessagesService) Insert(ctx context.Context, userID string, message *gmail.Message) MessengerClientResp {
+	insertCall := m.s.Insert(userID, message)
+	r := m.retrier.newRetry(ctx, false)
+	insertCall.Context(r.ctx)
+	return &retryingMessengerClientResp{retry: r, call: insertCall}
+}
+func (m *MessagesService) List(ctx context.Context, userID string, opts MessageListOptions) MessengerClientList {
+	listCall := m.s.List(userID).IncludeSpamTrash(opts.IncludeSpamTrash)
+	if opts.MaxResults > 0 {
+		listCall.MaxResults(opts.MaxResults)
+	}
+	if opts.PageToken != "" {
+		listCall.PageToken(opts.PageToken)
+	}
+	if opts.Query != "" {
+		listCall.Q(opts.Query)
+	}
+	if len(opts.LabelIDs) > 0 {
+		listCall.LabelIds(opts.LabelIDs...)
+	}
+	r := m.retrier.newRetry(ctx, true)
+	listCall.Context(r.ctx)
+	return &retryingMessengerClientList{retry: r, call: listCall}
+}
+func (m *MessagesService) Modify(ctx context.Context, userID string, messageID string, req *gmail.ModifyMessageRequest) MessengerClientResp {
+	modifyCall := m.s.Modify(userID, messageID, req)
+	r := m.retrier.newRetry(ctx, true)
+	modifyCall.Context(r.ctx)
+	return &retryingMessengerClientResp{retry: r, call: modifyCall}
+}
+func (m *MessagesService) Send(ctx context.Context, userID string, message *gmail.Message) MessengerClientResp {
+	sendCall := m.s.Send(userID, message)
+	r := m.retrier.newRetry(ctx, false)
+	sendCall.Context(r.ctx)
+	return &retryingMessengerClientResp{retry: r, call: sendCall}
+}
+func (m *MessagesService) Trash(ctx context.Context, userID string, messageID string) MessengerClientResp {
+	trashCall := m.s.Trash(userID, messageID)
+	r := m.retrier.newRetry(ctx, true)
+	trashCall.Context(r.ctx)
+	return &retryingMessengerClientResp{retry: r, call: trashCall}
+}
+func (m *MessagesService) Untrash(ctx context.Context, userID string, messageID string) MessengerClientResp {
+	untrashCall := m.s.Untrash(userID, messageID)
+	r := m.retrier.newRetry(ctx, true)
+	untrashCall.Context(r.ctx)
+	return &retryingMessengerClientResp{retry: r, call: untrashCall}
 }
 
 /*
@@ -77,47 +125,56 @@ type MessengerClientList interface {
 }
 
 type MessageBatchDeletorCall interface {
-	BatchDelete(string, *gmail.BatchDeleteMessagesRequest) MessengerClient
+	BatchDelete(context.Context, string, *gmail.BatchDeleteMessagesRequest) MessengerClient
 }
 
 type MessageBatchModifierCall interface {
-	BatchModify(string, *gmail.BatchModifyMessagesRequest) MessengerClient
+	BatchModify(context.Context, string, *gmail.BatchModifyMessagesRequest) MessengerClient
 }
 
 type MessageDeletorCall interface {
-	Delete(string, string) MessengerClient
+	Delete(context.Context, string, string) MessengerClient
 }
 
 type MessageGetterCall interface {
-	Get(string, string) MessengerClientResp
+	Get(context.Context, string, string) MessengerClientResp
 }
 
 type MessageImporterCall interface {
-	Import(string, *gmail.Message) MessengerClientResp
+	Import(context.Context, string, *gmail.Message) MessengerClientResp
 }
 
 type MessageInserterCall interface {
-	Insert(string, *gmail.Message) MessengerClientResp
+	Insert(context.Context, string, *gmail.Message) MessengerClientResp
+}
+
+// MessageListOptions holds the optional parameters of a messages list call.
+type MessageListOptions struct {
+	MaxResults       int64
+	PageToken        string
+	Query            string
+	LabelIDs         []string
+	IncludeSpamTrash bool
 }
 
 type MessageListerCall interface {
-	List(string, int64) MessengerClientList
+	List(context.Context, string, MessageListOptions) MessengerClientList
 }
 
 type MessageModifierCall interface {
-	Modify(string, string, *gmail.ModifyMessageRequest) MessengerClientResp
+	Modify(context.Context, string, string, *gmail.ModifyMessageRequest) MessengerClientResp
 }
 
 type MessageSenderCall interface {
-	Send(string, *gmail.Message) MessengerClientResp
+	Send(context.Context, string, *gmail.Message) MessengerClientResp
 }
 
 type MessageTrasherCall interface {
-	Trash(string, string) MessengerClientResp
+	Trash(context.Context, string, string) MessengerClientResp
 }
 
 type MessageUntrasherCall interface {
-	Untrash(string, string) MessengerClientResp
+	Untrash(context.Context, string, string) MessengerClientResp
 }
 
 type Messenger interface {
@@ -133,3 +190,31 @@ type Messenger interface {
 	MessageTrasherCall
 	MessageUntrasherCall
 }
+
+type retryingMessengerClientResp struct {
+	retry
+	call MessengerClientResp
+}
+
+func (c *retryingMessengerClientResp) Do(opts ...googleapi.CallOption) (*gmail.Message, error) {
+	var resp *gmail.Message
+	err := c.do(func() (err error) {
+		resp, err = c.call.Do(opts...)
+		return err
+	})
+	return resp, err
+}
+
+type retryingMessengerClientList struct {
+	retry
+	call MessengerClientList
+}
+
+func (c *retryingMessengerClientList) Do(opts ...googleapi.CallOption) (*gmail.ListMessagesResponse, error) {
+	var resp *gmail.ListMessagesResponse
+	err := c.do(func() (err error) {
+		resp, err = c.call.Do(opts...)
+		return err
+	})
+	return resp, err
+}
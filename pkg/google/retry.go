@@ -0,0 +1,183 @@
+package google
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	defaultMaxAttempts = 5
+	defaultBaseDelay   = 500 * time.Millisecond
+	defaultMaxDelay    = 30 * time.Second
+)
+
+// Retrier retries the gmail calls rejected by a rate limit or failing with a transient error, waiting a capped
+// exponential backoff with full jitter between the attempts. A nil Retrier runs every call once.
+type Retrier struct {
+	// MaxAttempts is the number of attempts including the first one.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	// MaxDelay caps the backoff, a Retry-After longer than MaxDelay is not waited and the error is returned.
+	MaxDelay time.Duration
+	// Timeout is the deadline of every gmail call including its retries, zero leaves the ctx of the call as is.
+	Timeout time.Duration
+	// jitter returns a random duration in [0, n), it is replaced in tests.
+	jitter func(n int64) int64
+}
+
+func NewRetrier() *Retrier {
+	return &Retrier{
+		MaxAttempts: defaultMaxAttempts,
+		BaseDelay:   defaultBaseDelay,
+		MaxDelay:    defaultMaxDelay,
+		jitter:      rand.Int63n,
+	}
+}
+
+/*
+Do runs the call until it succeeds, fails with an error that is not retryable or runs out of attempts.
+The errors retried are:
+  - 429 Too Many Requests.
+  - 403 with the rateLimitExceeded or userRateLimitExceeded reason.
+  - 5xx, only when the call is idempotent since gmail may have applied it anyway, e.g. a sent message.
+
+The call is not retried when the wait would outlast the deadline of ctx, the last error is returned instead.
+*/
+func (r *Retrier) Do(ctx context.Context, idempotent bool, call func() error) error {
+	if r == nil {
+		return call()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= r.MaxAttempts {
+			return err
+		}
+
+		retryAfter, ok := retryable(err, idempotent)
+		if !ok || retryAfter > r.MaxDelay {
+			return err
+		}
+
+		delay := r.backoff(attempt)
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns a random delay between zero and BaseDelay*2^(attempt-1) capped by MaxDelay.
+func (r *Retrier) backoff(attempt int) time.Duration {
+	ceiling := r.MaxDelay
+	if shift := attempt - 1; shift < 32 {
+		if exp := r.BaseDelay << uint(shift); exp > 0 && exp < ceiling {
+			ceiling = exp
+		}
+	}
+
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(r.jitter(int64(ceiling)))
+}
+
+// retryable reports whether the error is worth another attempt and the wait the Retry-After header asks for.
+func retryable(err error, idempotent bool) (time.Duration, bool) {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return 0, false
+	}
+
+	switch {
+	case apiErr.Code == http.StatusTooManyRequests:
+	case apiErr.Code == http.StatusForbidden && isRateLimitReason(apiErr):
+	case apiErr.Code >= http.StatusInternalServerError && idempotent:
+	default:
+		return 0, false
+	}
+
+	return retryAfter(apiErr.Header), true
+}
+
+func isRateLimitReason(apiErr *googleapi.Error) bool {
+	for _, item := range apiErr.Errors {
+		if item.Reason == "rateLimitExceeded" || item.Reason == "userRateLimitExceeded" {
+			return true
+		}
+	}
+	return false
+}
+
+// retryAfter parses the Retry-After header, which holds either a number of seconds or a http date.
+func retryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
+}
+
+// retry holds what the retrying clients need to retry the Do of a call.
+type retry struct {
+	ctx        context.Context
+	cancel     context.CancelFunc
+	retrier    *Retrier
+	idempotent bool
+}
+
+// newRetry bounds ctx by the Timeout of the retrier, the gmail call must be given the ctx of the returned retry.
+func (r *Retrier) newRetry(ctx context.Context, idempotent bool) retry {
+	cancel := context.CancelFunc(func() {})
+	if r != nil && r.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+	}
+	return retry{ctx: ctx, cancel: cancel, retrier: r, idempotent: idempotent}
+}
+
+func (r retry) do(call func() error) error {
+	defer r.cancel()
+	return r.retrier.Do(r.ctx, r.idempotent, call)
+}
+
+// retryingCall retries the calls whose Do only returns an error, e.g. the delete calls.
+type retryingCall struct {
+	retry
+	call interface {
+		Do(opts ...googleapi.CallOption) error
+	}
+}
+
+func (c *retryingCall) Do(opts ...googleapi.CallOption) error {
+	return c.do(func() error {
+		return c.call.Do(opts...)
+	})
+}
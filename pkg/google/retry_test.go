@@ -0,0 +1,311 @@
+package google
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+// scriptedResponse is answered by the scripted server before it falls back to a successful response.
+type scriptedResponse struct {
+	status     int
+	body       string
+	retryAfter string
+}
+
+// newScriptedGmailService returns a gmail service whose server answers the scripted responses in order.
+func newScriptedGmailService(t *testing.T, script []scriptedResponse) (*gmail.Service, func() int) {
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+
+		w.Header().Set("Content-Type", "application/json")
+		if requests > len(script) {
+			_, _ = w.Write([]byte(`{"id":"1"}`))
+			return
+		}
+
+		response := script[requests-1]
+		if response.retryAfter != "" {
+			w.Header().Set("Retry-After", response.retryAfter)
+		}
+		w.WriteHeader(response.status)
+		_, _ = w.Write([]byte(response.body))
+	}))
+	t.Cleanup(server.Close)
+
+	svc, err := gmail.NewService(context.Background(), option.WithHTTPClient(server.Client()), option.WithEndpoint(server.URL+"/"))
+	if err != nil {
+		t.Fatalf("cannot create the gmail service: %v", err)
+	}
+
+	return svc, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+}
+
+func newTestRetrier() *Retrier {
+	return &Retrier{
+		MaxAttempts: 4,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    10 * time.Millisecond,
+		jitter:      func(n int64) int64 { return n - 1 },
+	}
+}
+
+func rateLimitBody(code int, reason string) string {
+	return fmt.Sprintf(`{"error":{"code":%d,"message":"limited","errors":[{"reason":%q,"message":"limited"}]}}`, code, reason)
+}
+
+func TestRetrierGmailCalls(t *testing.T) {
+	testcases := []struct {
+		name             string
+		script           []scriptedResponse
+		send             bool
+		expectedCode     int
+		expectedRequests int
+	}{
+		{
+			name:             "success - first attempt.",
+			expectedRequests: 1,
+		},
+		{
+			name: "success - transient 5xx are retried.",
+			script: []scriptedResponse{
+				{status: http.StatusServiceUnavailable},
+				{status: http.StatusBadGateway},
+			},
+			expectedRequests: 3,
+		},
+		{
+			name: "success - 429 is retried.",
+			script: []scriptedResponse{
+				{status: http.StatusTooManyRequests, body: rateLimitBody(http.StatusTooManyRequests, "rateLimitExceeded")},
+			},
+			expectedRequests: 2,
+		},
+		{
+			name: "success - 403 rate limit reasons are retried.",
+			script: []scriptedResponse{
+				{status: http.StatusForbidden, body: rateLimitBody(http.StatusForbidden, "rateLimitExceeded")},
+				{status: http.StatusForbidden, body: rateLimitBody(http.StatusForbidden, "userRateLimitExceeded")},
+			},
+			expectedRequests: 3,
+		},
+		{
+			name: "success - a non idempotent call is retried on rate limits.",
+			script: []scriptedResponse{
+				{status: http.StatusTooManyRequests},
+			},
+			send:             true,
+			expectedRequests: 2,
+		},
+		{
+			name: "failure - 403 without a rate limit reason is not retried.",
+			script: []scriptedResponse{
+				{status: http.StatusForbidden, body: rateLimitBody(http.StatusForbidden, "insufficientPermissions")},
+			},
+			expectedCode:     http.StatusForbidden,
+			expectedRequests: 1,
+		},
+		{
+			name: "failure - 404 is not retried.",
+			script: []scriptedResponse{
+				{status: http.StatusNotFound},
+			},
+			expectedCode:     http.StatusNotFound,
+			expectedRequests: 1,
+		},
+		{
+			name: "failure - a non idempotent call is not retried on 5xx.",
+			script: []scriptedResponse{
+				{status: http.StatusInternalServerError},
+			},
+			send:             true,
+			expectedCode:     http.StatusInternalServerError,
+			expectedRequests: 1,
+		},
+		{
+			name: "failure - attempts are exhausted.",
+			script: []scriptedResponse{
+				{status: http.StatusTooManyRequests},
+				{status: http.StatusTooManyRequests},
+				{status: http.StatusTooManyRequests},
+				{status: http.StatusTooManyRequests},
+			},
+			expectedCode:     http.StatusTooManyRequests,
+			expectedRequests: 4,
+		},
+		{
+			name: "failure - Retry-After longer than the maximum delay.",
+			script: []scriptedResponse{
+				{status: http.StatusTooManyRequests, retryAfter: "120"},
+			},
+			expectedCode:     http.StatusTooManyRequests,
+			expectedRequests: 1,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			svc, requests := newScriptedGmailService(t, test.script)
+			messages := NewMessagesService(svc.Users.Messages, newTestRetrier())
+
+			var err error
+			if test.send {
+				_, err = messages.Send(context.Background(), "me", &gmail.Message{Raw: "raw"}).Do()
+			} else {
+				_, err = messages.Get(context.Background(), "me", "1").Do()
+			}
+
+			if test.expectedCode == 0 {
+				assert.Nil(t, err)
+			} else {
+				var apiErr *googleapi.Error
+				assert.True(t, errors.As(err, &apiErr))
+				assert.Equal(t, test.expectedCode, apiErr.Code)
+			}
+			assert.Equal(t, test.expectedRequests, requests())
+		})
+	}
+}
+
+func TestRetrierHonorsRetryAfter(t *testing.T) {
+	svc, requests := newScriptedGmailService(t, []scriptedResponse{
+		{status: http.StatusTooManyRequests, retryAfter: "1"},
+	})
+	retrier := newTestRetrier()
+	retrier.MaxDelay = 2 * time.Second
+	labels := NewLabelsService(svc.Users.Labels, retrier)
+
+	start := time.Now()
+	_, err := labels.Get(context.Background(), "me", "1").Do()
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	assert.Equal(t, 2, requests())
+}
+
+func TestRetrierRespectsContext(t *testing.T) {
+	t.Run("failure - the wait outlasts the deadline.", func(t *testing.T) {
+		svc, requests := newScriptedGmailService(t, []scriptedResponse{
+			{status: http.StatusTooManyRequests, retryAfter: "1"},
+		})
+		retrier := newTestRetrier()
+		retrier.MaxDelay = 2 * time.Second
+		labels := NewLabelsService(svc.Users.Labels, retrier)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := labels.Get(ctx, "me", "1").Do()
+		var apiErr *googleapi.Error
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusTooManyRequests, apiErr.Code)
+		assert.Less(t, int64(time.Since(start)), int64(100*time.Millisecond))
+		assert.Equal(t, 1, requests())
+	})
+
+	t.Run("failure - the ctx is canceled while waiting.", func(t *testing.T) {
+		retrier := newTestRetrier()
+		retrier.BaseDelay = time.Second
+		retrier.MaxDelay = time.Second
+
+		ctx, cancel := context.WithCancel(context.Background())
+		attempts := 0
+		err := retrier.Do(ctx, true, func() error {
+			attempts++
+			cancel()
+			return &googleapi.Error{Code: http.StatusServiceUnavailable}
+		})
+		assert.Equal(t, context.Canceled, err)
+		assert.Equal(t, 1, attempts)
+	})
+}
+
+func TestRetrierTimeout(t *testing.T) {
+	testcases := []struct {
+		name   string
+		status int
+		delay  time.Duration
+	}{
+		{
+			name:   "failure - a slow call is bound by the timeout.",
+			status: http.StatusOK,
+			delay:  300 * time.Millisecond,
+		},
+		{
+			name:   "failure - a call retried again and again is bound by the timeout.",
+			status: http.StatusTooManyRequests,
+			delay:  40 * time.Millisecond,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-time.After(test.delay):
+				case <-r.Context().Done():
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(test.status)
+				_, _ = w.Write([]byte(rateLimitBody(test.status, "rateLimitExceeded")))
+			}))
+			t.Cleanup(server.Close)
+
+			svc, err := gmail.NewService(context.Background(), option.WithHTTPClient(server.Client()), option.WithEndpoint(server.URL+"/"))
+			assert.Nil(t, err)
+
+			retrier := newTestRetrier()
+			retrier.MaxAttempts = 100
+			retrier.MaxDelay = time.Millisecond
+			retrier.Timeout = 100 * time.Millisecond
+			labels := NewLabelsService(svc.Users.Labels, retrier)
+
+			start := time.Now()
+			_, err = labels.Get(context.Background(), "me", "1").Do()
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+			assert.Less(t, int64(time.Since(start)), int64(retrier.Timeout+50*time.Millisecond))
+		})
+	}
+}
+
+func TestRetrierBackoff(t *testing.T) {
+	retrier := &Retrier{
+		BaseDelay: 100 * time.Millisecond,
+		MaxDelay:  time.Second,
+		jitter:    func(n int64) int64 { return n },
+	}
+
+	assert.Equal(t, 100*time.Millisecond, retrier.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, retrier.backoff(2))
+	assert.Equal(t, 800*time.Millisecond, retrier.backoff(4))
+	assert.Equal(t, time.Second, retrier.backoff(5))
+	assert.Equal(t, time.Second, retrier.backoff(64))
+}
+
+func TestRetryAfter(t *testing.T) {
+	assert.Equal(t, time.Duration(0), retryAfter(http.Header{}))
+	assert.Equal(t, 3*time.Second, retryAfter(http.Header{"Retry-After": []string{"3"}}))
+
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	wait := retryAfter(http.Header{"Retry-After": []string{date}})
+	assert.True(t, wait > 58*time.Second && wait <= time.Minute)
+}
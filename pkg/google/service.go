@@ -5,16 +5,21 @@ import "google.golang.org/api/gmail/v1"
 type Service interface {
 	GetLabelsService() Labeler
 	GetMessagesService() Messenger
+	GetAttachmentsService() Attacher
+	GetThreadsService() Threader
+	GetDraftsService() Drafter
+	GetHistoryService() Historian
 }
 
 type GmailService struct {
-	Users    *gmail.UsersService
-	Labels   *LabelsService
-	Drafts   *DraftsService
-	Messages *MessagesService
-	History  *HistoryService
-	Settings *SettingsService
-	Threads  *ThreadsService
+	Users       *gmail.UsersService
+	Labels      *LabelsService
+	Drafts      *DraftsService
+	Messages    *MessagesService
+	Attachments *AttachmentsService
+	History     *HistoryService
+	Settings    *SettingsService
+	Threads     *ThreadsService
 }
 
 func (g *GmailService) GetLabelsService() Labeler {
@@ -25,18 +30,22 @@ func (g *GmailService) GetMessagesService() Messenger {
 	return g.Messages
 }
 
-type DraftsService struct {
-	*gmail.UsersDraftsService
+func (g *GmailService) GetAttachmentsService() Attacher {
+	return g.Attachments
 }
 
-type HistoryService struct {
-	*gmail.UsersHistoryService
+func (g *GmailService) GetThreadsService() Threader {
+	return g.Threads
 }
 
-type SettingsService struct {
-	*gmail.UsersSettingsService
+func (g *GmailService) GetDraftsService() Drafter {
+	return g.Drafts
+}
+
+func (g *GmailService) GetHistoryService() Historian {
+	return g.History
 }
 
-type ThreadsService struct {
-	*gmail.UsersThreadsService
+type SettingsService struct {
+	*gmail.UsersSettingsService
 }
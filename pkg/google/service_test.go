@@ -0,0 +1,58 @@
+package google
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+)
+
+func newTestGmailService(t *testing.T) (*gmail.Service, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"1"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	svc, err := gmail.NewService(context.Background(), option.WithHTTPClient(server.Client()), option.WithEndpoint(server.URL+"/"))
+	if err != nil {
+		t.Fatalf("cannot create the gmail service: %v", err)
+	}
+	return svc, &requests
+}
+
+func TestCallsUseContext(t *testing.T) {
+	svc, requests := newTestGmailService(t)
+	labels := NewLabelsService(svc.Users.Labels, nil)
+	messages := NewMessagesService(svc.Users.Messages, nil)
+
+	label, err := labels.Get(context.Background(), "me", "1").Do()
+	assert.Nil(t, err)
+	assert.Equal(t, "1", label.Id)
+
+	message, err := messages.Get(context.Background(), "me", "1").Do()
+	assert.Nil(t, err)
+	assert.Equal(t, "1", message.Id)
+	assert.Equal(t, int32(2), atomic.LoadInt32(requests))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = labels.Get(ctx, "me", "1").Do()
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = messages.List(ctx, "me", MessageListOptions{}).Do()
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = NewThreadsService(svc.Users.Threads, nil).Get(ctx, "me", "1").Do()
+	assert.ErrorIs(t, err, context.Canceled)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(requests), "the canceled calls must not reach gmail")
+}
@@ -0,0 +1,154 @@
+package google
+
+import (
+	"context"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+type ThreadsService struct {
+	s       *gmail.UsersThreadsService
+	retrier *Retrier
+}
+
+func NewThreadsService(threadsSvc *gmail.UsersThreadsService, retrier *Retrier) *ThreadsService {
+	return &ThreadsService{
+		s:       threadsSvc,
+		retrier: retrier,
+	}
+}
+
+func (t *ThreadsService) Delete(ctx context.Context, userID string, threadID string) ThreaderClient {
+	deleteCall := t.s.Delete(userID, threadID)
+	r := t.retrier.newRetry(ctx, true)
+	deleteCall.Context(r.ctx)
+	return &retryingCall{retry: r, call: deleteCall}
+}
+func (t *ThreadsService) Get(ctx context.Context, userID string, threadID string) ThreaderClientResp {
+	getCall := t.s.Get(userID, threadID)
+	r := t.retrier.newRetry(ctx, true)
+	getCall.Context(r.ctx)
+	return &retryingThreaderClientResp{retry: r, call: getCall}
+}
+
+// List accepts the same options as the messages list call since gmail shares the parameters between both.
+func (t *ThreadsService) List(ctx context.Context, userID string, opts MessageListOptions) ThreaderClientList {
+	listCall := t.s.List(userID).IncludeSpamTrash(opts.IncludeSpamTrash)
+	if opts.MaxResults > 0 {
+		listCall.MaxResults(opts.MaxResults)
+	}
+	if opts.PageToken != "" {
+		listCall.PageToken(opts.PageToken)
+	}
+	if opts.Query != "" {
+		listCall.Q(opts.Query)
+	}
+	if len(opts.LabelIDs) > 0 {
+		listCall.LabelIds(opts.LabelIDs...)
+	}
+	r := t.retrier.newRetry(ctx, true)
+	listCall.Context(r.ctx)
+	return &retryingThreaderClientList{retry: r, call: listCall}
+}
+func (t *ThreadsService) Modify(ctx context.Context, userID string, threadID string, req *gmail.ModifyThreadRequest) ThreaderClientResp {
+	modifyCall := t.s.Modify(userID, threadID, req)
+	r := t.retrier.newRetry(ctx, true)
+	modifyCall.Context(r.ctx)
+	return &retryingThreaderClientResp{retry: r, call: modifyCall}
+}
+func (t *ThreadsService) Trash(ctx context.Context, userID string, threadID string) ThreaderClientResp {
+	trashCall := t.s.Trash(userID, threadID)
+	r := t.retrier.newRetry(ctx, true)
+	trashCall.Context(r.ctx)
+	return &retryingThreaderClientResp{retry: r, call: trashCall}
+}
+func (t *ThreadsService) Untrash(ctx context.Context, userID string, threadID string) ThreaderClientResp {
+	untrashCall := t.s.Untrash(userID, threadID)
+	r := t.retrier.newRetry(ctx, true)
+	untrashCall.Context(r.ctx)
+	return &retryingThreaderClientResp{retry: r, call: untrashCall}
+}
+
+/*
+ The listed interfaces represents an abstraction of the *gmail.UsersThreadsService and its methods and actioners:
+	Delete -> Do()
+	Get -> Do()
+	List -> Do()
+	Modify -> Do()
+	Trash -> Do()
+	Untrash -> Do()
+*/
+
+type ThreaderClient interface {
+	Do(opts ...googleapi.CallOption) error
+}
+
+type ThreaderClientResp interface {
+	Do(opts ...googleapi.CallOption) (*gmail.Thread, error)
+}
+
+type ThreaderClientList interface {
+	Do(opts ...googleapi.CallOption) (*gmail.ListThreadsResponse, error)
+}
+
+type ThreadDeletorCall interface {
+	Delete(context.Context, string, string) ThreaderClient
+}
+
+type ThreadGetterCall interface {
+	Get(context.Context, string, string) ThreaderClientResp
+}
+
+type ThreadListerCall interface {
+	List(context.Context, string, MessageListOptions) ThreaderClientList
+}
+
+type ThreadModifierCall interface {
+	Modify(context.Context, string, string, *gmail.ModifyThreadRequest) ThreaderClientResp
+}
+
+type ThreadTrasherCall interface {
+	Trash(context.Context, string, string) ThreaderClientResp
+}
+
+type ThreadUntrasherCall interface {
+	Untrash(context.Context, string, string) ThreaderClientResp
+}
+
+type Threader interface {
+	ThreadDeletorCall
+	ThreadGetterCall
+	ThreadListerCall
+	ThreadModifierCall
+	ThreadTrasherCall
+	ThreadUntrasherCall
+}
+
+type retryingThreaderClientResp struct {
+	retry
+	call ThreaderClientResp
+}
+
+func (c *retryingThreaderClientResp) Do(opts ...googleapi.CallOption) (*gmail.Thread, error) {
+	var resp *gmail.Thread
+	err := c.do(func() (err error) {
+		resp, err = c.call.Do(opts...)
+		return err
+	})
+	return resp, err
+}
+
+type retryingThreaderClientList struct {
+	retry
+	call ThreaderClientList
+}
+
+func (c *retryingThreaderClientList) Do(opts ...googleapi.CallOption) (*gmail.ListThreadsResponse, error) {
+	var resp *gmail.ListThreadsResponse
+	err := c.do(func() (err error) {
+		resp, err = c.call.Do(opts...)
+		return err
+	})
+	return resp, err
+}
@@ -0,0 +1,38 @@
+/*
+Package keyconfig reads the key sets configured through the environment, e.g. the json web token keys and
+the key encryption keys of the oauth tokens. A key set is a comma separated list of key ids, the material of
+every key is read from `<PREFIX><ID>_FILE` or `<PREFIX><ID>`.
+*/
+package keyconfig
+
+import (
+	"os"
+	"strings"
+)
+
+// IDs splits the comma separated list of key ids, blank ids are skipped.
+func IDs(value string) []string {
+	ids := make([]string, 0)
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// EnvName returns the <ID> of the key id in the configuration, the id in upper case with dashes and dots
+// replaced by underscores.
+func EnvName(id string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(id))
+}
+
+// Material returns the content of the file at `<prefix><ID>_FILE` when it is set, or the value of `<prefix><ID>`.
+// get is usually viper.GetString.
+func Material(get func(string) string, prefix string, id string) ([]byte, error) {
+	name := prefix + EnvName(id)
+	if path := get(name + "_FILE"); path != "" {
+		return os.ReadFile(path)
+	}
+	return []byte(get(name)), nil
+}
@@ -0,0 +1,57 @@
+package keyconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIDs(t *testing.T) {
+	assert.Equal(t, []string{"2026-10", "2026-04"}, IDs(" 2026-10, ,2026-04,"))
+	assert.Empty(t, IDs(""))
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "2026_10", EnvName("2026-10"))
+	assert.Equal(t, "KEY_V1_2", EnvName("key.v1-2"))
+}
+
+func TestMaterial(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	assert.Nil(t, os.WriteFile(path, []byte("from file"), 0o600))
+
+	testcases := []struct {
+		name        string
+		env         map[string]string
+		expected    string
+		assertError func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+	}{
+		{
+			name:        "success - material is read from the value.",
+			env:         map[string]string{"KEY_2026_10": "from value"},
+			expected:    "from value",
+			assertError: assert.Nil,
+		},
+		{
+			name:        "success - the file takes precedence over the value.",
+			env:         map[string]string{"KEY_2026_10": "from value", "KEY_2026_10_FILE": path},
+			expected:    "from file",
+			assertError: assert.Nil,
+		},
+		{
+			name:        "failure - the file does not exist.",
+			env:         map[string]string{"KEY_2026_10_FILE": filepath.Join(t.TempDir(), "missing")},
+			assertError: assert.NotNil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			material, err := Material(func(key string) string { return test.env[key] }, "KEY_", "2026-10")
+			test.assertError(t, err)
+			assert.Equal(t, test.expected, string(material))
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package keyring
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Supported signing algorithms.
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	EdDSA = "EdDSA"
+)
+
+var (
+	ErrUnknownKey      = errors.New("the token was signed with an unknown key.")
+	ErrMissingKeyID    = errors.New("the token does not have a kid header.")
+	ErrAlgMismatch     = errors.New("the token algorithm does not match its key.")
+	ErrVerificationKey = errors.New("the key can only verify tokens.")
+)
+
+// Key is a signing key identified by its kid. Keys built from a public key can only verify tokens, they are kept
+// to accept the tokens signed before a key rotation.
+type Key struct {
+	ID        string
+	Algorithm string
+	method    jwt.SigningMethod
+	private   interface{}
+	public    interface{}
+}
+
+// NewHMACKey returns a HS256 key, the secret is used to sign and verify tokens and it is never published.
+func NewHMACKey(id string, secret []byte) *Key {
+	return &Key{ID: id, Algorithm: HS256, method: jwt.SigningMethodHS256, private: secret, public: secret}
+}
+
+// NewRSAKey returns a RS256 key, private may be nil for verification only keys.
+func NewRSAKey(id string, private *rsa.PrivateKey, public *rsa.PublicKey) *Key {
+	key := &Key{ID: id, Algorithm: RS256, method: jwt.SigningMethodRS256, public: public}
+	if private != nil {
+		key.private = private
+		key.public = &private.PublicKey
+	}
+	return key
+}
+
+// NewEdDSAKey returns an Ed25519 key, private may be nil for verification only keys.
+func NewEdDSAKey(id string, private ed25519.PrivateKey, public ed25519.PublicKey) *Key {
+	key := &Key{ID: id, Algorithm: EdDSA, method: jwt.SigningMethodEdDSA, public: public}
+	if private != nil {
+		key.private = private
+		key.public = private.Public()
+	}
+	return key
+}
+
+// ParseKey builds a key from its algorithm and material. HS256 keys take the raw secret, RS256 and EdDSA keys take
+// a PEM encoded private key or, for verification only keys, a PEM encoded public key.
+func ParseKey(id string, algorithm string, material []byte) (*Key, error) {
+	switch algorithm {
+	case HS256:
+		if len(material) == 0 {
+			return nil, fmt.Errorf("the secret of key %s is empty", id)
+		}
+		return NewHMACKey(id, material), nil
+	case RS256:
+		if private, err := jwt.ParseRSAPrivateKeyFromPEM(material); err == nil {
+			return NewRSAKey(id, private, nil), nil
+		}
+		public, err := jwt.ParseRSAPublicKeyFromPEM(material)
+		if err != nil {
+			return nil, fmt.Errorf("the key %s is not a RS256 pem key: %w", id, err)
+		}
+		return NewRSAKey(id, nil, public), nil
+	case EdDSA:
+		if private, err := jwt.ParseEdPrivateKeyFromPEM(material); err == nil {
+			return NewEdDSAKey(id, private.(ed25519.PrivateKey), nil), nil
+		}
+		public, err := jwt.ParseEdPublicKeyFromPEM(material)
+		if err != nil {
+			return nil, fmt.Errorf("the key %s is not an EdDSA pem key: %w", id, err)
+		}
+		return NewEdDSAKey(id, nil, public.(ed25519.PublicKey)), nil
+	}
+	return nil, fmt.Errorf("the algorithm %q of key %s is not supported", algorithm, id)
+}
+
+// CanSign reports whether the key holds the private material to sign tokens.
+func (k *Key) CanSign() bool {
+	return k.private != nil
+}
+
+/*
+KeyRing holds the keys that verify mailx tokens and the key that signs the new ones.
+To rotate a key:
+  - add the new key to the ring and make it the signing key.
+  - keep the old key, or only its public key, until the tokens it signed expire.
+  - remove the old key.
+*/
+type KeyRing struct {
+	keys    []*Key
+	byID    map[string]*Key
+	signing *Key
+}
+
+// New returns a key ring that signs with the key signingKeyID.
+func New(signingKeyID string, keys ...*Key) (*KeyRing, error) {
+	ring := &KeyRing{byID: make(map[string]*Key, len(keys))}
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, errors.New("every key requires an id")
+		}
+		if _, ok := ring.byID[key.ID]; ok {
+			return nil, fmt.Errorf("the key id %s is duplicated", key.ID)
+		}
+		ring.keys = append(ring.keys, key)
+		ring.byID[key.ID] = key
+	}
+
+	signing, ok := ring.byID[signingKeyID]
+	if !ok {
+		return nil, fmt.Errorf("the signing key %s is not in the key ring", signingKeyID)
+	}
+	if !signing.CanSign() {
+		return nil, fmt.Errorf("the signing key %s: %w", signingKeyID, ErrVerificationKey)
+	}
+	ring.signing = signing
+	return ring, nil
+}
+
+// Sign signs the claims with the signing key, the kid header identifies the key.
+func (r *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(r.signing.method, claims)
+	token.Header["kid"] = r.signing.ID
+	return token.SignedString(r.signing.private)
+}
+
+// Keyfunc returns the key identified by the kid header of the token. The algorithm of the token must be the
+// algorithm of the key, otherwise a public key could be used as a HS256 secret.
+func (r *KeyRing) Keyfunc(token *jwt.Token) (interface{}, error) {
+	id, _ := token.Header["kid"].(string)
+	if id == "" {
+		return nil, ErrMissingKeyID
+	}
+
+	key, ok := r.byID[id]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	if token.Method.Alg() != key.Algorithm {
+		return nil, ErrAlgMismatch
+	}
+	return key.public, nil
+}
+
+// JSONWebKey is the public part of a key as described by RFC 7517.
+type JSONWebKey struct {
+	KeyID     string `json:"kid"`
+	KeyType   string `json:"kty"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS returns the public keys of the ring. HS256 keys are symmetric secrets and they are never published.
+func (r *KeyRing) JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(r.keys))}
+	for _, key := range r.keys {
+		if jwk, ok := publicJWK(key); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
+
+func publicJWK(key *Key) (JSONWebKey, bool) {
+	jwk := JSONWebKey{KeyID: key.ID, Algorithm: key.Algorithm, Use: "sig"}
+	switch public := key.public.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	default:
+		return jwk, false
+	}
+	return jwk, true
+}
@@ -0,0 +1,202 @@
+package keyring
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate rsa key: %v", err)
+	}
+	return key
+}
+
+func newEdDSAKey(t *testing.T) ed25519.PrivateKey {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("cannot generate ed25519 key: %v", err)
+	}
+	return key
+}
+
+func claims() jwt.StandardClaims {
+	return jwt.StandardClaims{Subject: "1", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	rsaKey := newRSAKey(t)
+	edKey := newEdDSAKey(t)
+
+	testcases := []struct {
+		name string
+		key  *Key
+	}{
+		{
+			name: "success - HS256 key.",
+			key:  NewHMACKey("hmac", []byte("secret")),
+		},
+		{
+			name: "success - RS256 key.",
+			key:  NewRSAKey("rsa", rsaKey, nil),
+		},
+		{
+			name: "success - EdDSA key.",
+			key:  NewEdDSAKey("ed", edKey, nil),
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			ring, err := New(test.key.ID, test.key)
+			assert.Nil(t, err)
+
+			value, err := ring.Sign(claims())
+			assert.Nil(t, err)
+
+			token, err := jwt.ParseWithClaims(value, &jwt.StandardClaims{}, ring.Keyfunc)
+			assert.Nil(t, err)
+			assert.Equal(t, test.key.ID, token.Header["kid"])
+			assert.Equal(t, test.key.Algorithm, token.Method.Alg())
+			assert.Equal(t, "1", token.Claims.(*jwt.StandardClaims).Subject)
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey := newRSAKey(t)
+	oldRing, err := New("old", NewRSAKey("old", oldKey, nil))
+	assert.Nil(t, err)
+	oldToken, err := oldRing.Sign(claims())
+	assert.Nil(t, err)
+
+	// the new ring signs with a new key and keeps only the public part of the old key.
+	ring, err := New("new", NewEdDSAKey("new", newEdDSAKey(t), nil), NewRSAKey("old", nil, &oldKey.PublicKey))
+	assert.Nil(t, err)
+
+	_, err = jwt.ParseWithClaims(oldToken, &jwt.StandardClaims{}, ring.Keyfunc)
+	assert.Nil(t, err)
+
+	newToken, err := ring.Sign(claims())
+	assert.Nil(t, err)
+	token, err := jwt.ParseWithClaims(newToken, &jwt.StandardClaims{}, ring.Keyfunc)
+	assert.Nil(t, err)
+	assert.Equal(t, "new", token.Header["kid"])
+}
+
+func TestKeyfunc(t *testing.T) {
+	rsaKey := newRSAKey(t)
+	ring, err := New("rsa", NewRSAKey("rsa", rsaKey, nil))
+	assert.Nil(t, err)
+
+	unknown := jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+	unknown.Header["kid"] = "unknown"
+
+	// a HS256 token signed with the public key as secret must not be accepted.
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+	confused.Header["kid"] = "rsa"
+
+	testcases := []struct {
+		name        string
+		token       *jwt.Token
+		expectedErr error
+	}{
+		{
+			name:        "failure - missing kid header.",
+			token:       jwt.NewWithClaims(jwt.SigningMethodRS256, claims()),
+			expectedErr: ErrMissingKeyID,
+		},
+		{
+			name:        "failure - unknown kid.",
+			token:       unknown,
+			expectedErr: ErrUnknownKey,
+		},
+		{
+			name:        "failure - algorithm does not match the key.",
+			token:       confused,
+			expectedErr: ErrAlgMismatch,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			key, err := ring.Keyfunc(test.token)
+			assert.Nil(t, key)
+			assert.Equal(t, test.expectedErr, err)
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	rsaKey := newRSAKey(t)
+
+	testcases := []struct {
+		name      string
+		signingID string
+		keys      []*Key
+	}{
+		{
+			name:      "failure - signing key is not in the ring.",
+			signingID: "missing",
+			keys:      []*Key{NewHMACKey("hmac", []byte("secret"))},
+		},
+		{
+			name:      "failure - signing key cannot sign.",
+			signingID: "rsa",
+			keys:      []*Key{NewRSAKey("rsa", nil, &rsaKey.PublicKey)},
+		},
+		{
+			name:      "failure - duplicated key id.",
+			signingID: "hmac",
+			keys:      []*Key{NewHMACKey("hmac", []byte("secret")), NewHMACKey("hmac", []byte("other"))},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			ring, err := New(test.signingID, test.keys...)
+			assert.Nil(t, ring)
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	rsaKey := newRSAKey(t)
+	edKey := newEdDSAKey(t)
+	ring, err := New("hmac",
+		NewHMACKey("hmac", []byte("secret")),
+		NewRSAKey("rsa", rsaKey, nil),
+		NewEdDSAKey("ed", nil, edKey.Public().(ed25519.PublicKey)),
+	)
+	assert.Nil(t, err)
+
+	set := ring.JWKS()
+	assert.Len(t, set.Keys, 2)
+
+	assert.Equal(t, JSONWebKey{
+		KeyID:     "rsa",
+		KeyType:   "RSA",
+		Algorithm: RS256,
+		Use:       "sig",
+		N:         base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+		E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+	}, set.Keys[0])
+	assert.Equal(t, JSONWebKey{
+		KeyID:     "ed",
+		KeyType:   "OKP",
+		Algorithm: EdDSA,
+		Use:       "sig",
+		Curve:     "Ed25519",
+		X:         base64.RawURLEncoding.EncodeToString(edKey.Public().(ed25519.PublicKey)),
+	}, set.Keys[1])
+}
@@ -0,0 +1,52 @@
+package keyring
+
+import (
+	"fmt"
+
+	"github.com/orlandorode97/mailx-google-service/pkg/keyconfig"
+)
+
+// defaultKeyID identifies the HS256 key built from JWT_SIGNING_KEY when no key ring is configured.
+const defaultKeyID = "default"
+
+/*
+Load builds the key ring from the configuration, get is usually viper.GetString.
+The configuration is:
+  - JWT_KEYS: comma separated list of key ids, e.g. `2026-10,2026-04`.
+  - JWT_SIGNING_KID: id of the key that signs new tokens, it defaults to the first key of JWT_KEYS.
+  - JWT_KEY_<ID>_ALG: HS256, RS256 or EdDSA.
+  - JWT_KEY_<ID>_FILE: path of the PEM file, or JWT_KEY_<ID> with the PEM content or the HS256 secret.
+
+See keyconfig for how the ids are listed and named.
+When JWT_KEYS is empty, JWT_SIGNING_KEY is loaded as a HS256 key to keep the former configuration working.
+*/
+func Load(get func(string) string) (*KeyRing, error) {
+	ids := keyconfig.IDs(get("JWT_KEYS"))
+	if len(ids) == 0 {
+		secret := get("JWT_SIGNING_KEY")
+		if secret == "" {
+			return nil, fmt.Errorf("neither JWT_KEYS nor JWT_SIGNING_KEY are configured")
+		}
+		return New(defaultKeyID, NewHMACKey(defaultKeyID, []byte(secret)))
+	}
+
+	keys := make([]*Key, 0, len(ids))
+	for _, id := range ids {
+		material, err := keyconfig.Material(get, "JWT_KEY_", id)
+		if err != nil {
+			return nil, fmt.Errorf("reading key %s: %w", id, err)
+		}
+
+		key, err := ParseKey(id, get("JWT_KEY_"+keyconfig.EnvName(id)+"_ALG"), material)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	signingID := get("JWT_SIGNING_KID")
+	if signingID == "" {
+		signingID = ids[0]
+	}
+	return New(signingID, keys...)
+}
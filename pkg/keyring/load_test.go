@@ -0,0 +1,136 @@
+package keyring
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("cannot write pem file: %v", err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	edKey := newEdDSAKey(t)
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	assert.Nil(t, err)
+	edPath := writePEM(t, "PRIVATE KEY", edDER)
+
+	rsaKey := newRSAKey(t)
+	rsaPublicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	assert.Nil(t, err)
+	rsaPublicPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: rsaPublicDER}))
+
+	testcases := []struct {
+		name         string
+		env          map[string]string
+		expectedKid  string
+		expectedAlg  string
+		expectedJWKS int
+		expectedErr  bool
+	}{
+		{
+			name:        "success - former JWT_SIGNING_KEY configuration.",
+			env:         map[string]string{"JWT_SIGNING_KEY": "secret"},
+			expectedKid: "default",
+			expectedAlg: HS256,
+		},
+		{
+			name: "success - key ring from pem files and variables.",
+			env: map[string]string{
+				"JWT_KEYS":             "2026-10, 2026-04",
+				"JWT_KEY_2026_10_ALG":  EdDSA,
+				"JWT_KEY_2026_10_FILE": edPath,
+				"JWT_KEY_2026_04_ALG":  RS256,
+				"JWT_KEY_2026_04":      rsaPublicPEM,
+				"JWT_SIGNING_KEY":      "ignored",
+			},
+			expectedKid:  "2026-10",
+			expectedAlg:  EdDSA,
+			expectedJWKS: 2,
+		},
+		{
+			name: "success - signing key selected by JWT_SIGNING_KID.",
+			env: map[string]string{
+				"JWT_KEYS":        "a,b",
+				"JWT_KEY_A_ALG":   HS256,
+				"JWT_KEY_A":       "secret-a",
+				"JWT_KEY_B_ALG":   HS256,
+				"JWT_KEY_B":       "secret-b",
+				"JWT_SIGNING_KID": "b",
+			},
+			expectedKid: "b",
+			expectedAlg: HS256,
+		},
+		{
+			name:        "failure - nothing is configured.",
+			env:         map[string]string{},
+			expectedErr: true,
+		},
+		{
+			name: "failure - unsupported algorithm.",
+			env: map[string]string{
+				"JWT_KEYS":      "a",
+				"JWT_KEY_A_ALG": "none",
+				"JWT_KEY_A":     "secret",
+			},
+			expectedErr: true,
+		},
+		{
+			name: "failure - missing pem file.",
+			env: map[string]string{
+				"JWT_KEYS":       "a",
+				"JWT_KEY_A_ALG":  RS256,
+				"JWT_KEY_A_FILE": filepath.Join(t.TempDir(), "missing.pem"),
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			ring, err := Load(func(key string) string { return test.env[key] })
+			if test.expectedErr {
+				assert.Nil(t, ring)
+				assert.NotNil(t, err)
+				return
+			}
+
+			assert.Nil(t, err)
+			value, err := ring.Sign(claims())
+			assert.Nil(t, err)
+
+			token, err := jwt.ParseWithClaims(value, &jwt.StandardClaims{}, ring.Keyfunc)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expectedKid, token.Header["kid"])
+			assert.Equal(t, test.expectedAlg, token.Method.Alg())
+			assert.Len(t, ring.JWKS().Keys, test.expectedJWKS)
+		})
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	edKey := newEdDSAKey(t)
+	publicDER, err := x509.MarshalPKIXPublicKey(edKey.Public().(ed25519.PublicKey))
+	assert.Nil(t, err)
+
+	key, err := ParseKey("ed", EdDSA, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+	assert.Nil(t, err)
+	assert.False(t, key.CanSign())
+
+	_, err = ParseKey("ed", EdDSA, []byte("not a pem"))
+	assert.NotNil(t, err)
+
+	_, err = ParseKey("hmac", HS256, nil)
+	assert.NotNil(t, err)
+}
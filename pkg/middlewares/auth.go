@@ -3,63 +3,105 @@ package middlewares
 import (
 	"context"
 	"net/http"
+	"strings"
 
-	"github.com/golang-jwt/jwt/v4"
 	"github.com/orlandorode97/mailx-google-service/auth"
+	"github.com/orlandorode97/mailx-google-service/pkg/keyring"
 	"github.com/orlandorode97/mailx-google-service/pkg/models"
-	"github.com/spf13/viper"
 )
 
 type contextMailxKey string
 
-var (
-	InvalidAuthKey contextMailxKey = "InvalidAuth"
-	UserIDKey      contextMailxKey = "UserID"
-)
+var principalKey contextMailxKey = "Principal"
 
-func Authentication(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
-		ctx := context.Background()
-		cookie, err := r.Cookie("mailx_google_auth")
-		if err != nil {
-			ctx = context.WithValue(r.Context(), InvalidAuthKey, models.ErrInvalidCookie{})
-			next.ServeHTTP(rw, r.WithContext(ctx))
-			return
-		}
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID    string
+	SessionID string
+	Scopes    []string
+}
 
-		if cookie.Value == "" {
-			ctx = context.WithValue(r.Context(), InvalidAuthKey, models.ErrInvalidCookie{})
-			next.ServeHTTP(rw, r.WithContext(ctx))
-			return
+// HasScope reports whether the json web token of the principal was issued with the scope.
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
 		}
+	}
+	return false
+}
 
-		token, err := jwt.ParseWithClaims(cookie.Value, &auth.MailxClaims{}, func(t *jwt.Token) (interface{}, error) {
-			return []byte(viper.GetString("JWT_SIGNING_KEY")), nil
-		})
+// WithPrincipal returns a copy of the context that carries the principal.
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// PrincipalFromContext returns the principal stored by the Authentication middleware.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// UserIDFromRequest returns the user id of the principal of the request, the decoders of the authenticated
+// endpoints read the user from it. A request without a principal is rejected with models.ErrUnauthenticated.
+func UserIDFromRequest(r *http.Request) (string, error) {
+	principal, ok := PrincipalFromContext(r.Context())
+	if !ok {
+		return "", models.ErrUnauthenticated{}
+	}
+	return principal.UserID, nil
+}
 
-		if err != nil {
-			e, ok := err.(*jwt.ValidationError)
-			if ok {
-				switch {
-				case e.Errors&jwt.ValidationErrorSignatureInvalid != 0:
-					ctx = context.WithValue(r.Context(), InvalidAuthKey, models.ErrInvalidSignature{})
-				case e.Errors&jwt.ValidationErrorMalformed != 0:
-					ctx = context.WithValue(r.Context(), InvalidAuthKey, models.ErrMalformedToken{})
-				case e.Errors&jwt.ValidationErrorNotValidYet != 0:
-					ctx = context.WithValue(r.Context(), InvalidAuthKey, models.ErrInactiveToken{})
-				case e.Errors&jwt.ValidationErrorExpired != 0:
-					ctx = context.WithValue(r.Context(), InvalidAuthKey, models.ErrExpiredToken{})
-				case e.Inner != nil:
-					ctx = context.WithValue(r.Context(), InvalidAuthKey, e.Inner)
-
-				}
+/*
+Authentication returns a middleware that verifies the json web token of the request, see auth.TokenFromRequest
+for where the token is read from. The keys and the denylist must be the same ones given to the auth service.
+
+Requests without a valid token are rejected with a 401, and tokens without the mailbox scope with a 403, through
+models.ErrorEncoder and never reach the wrapped handler, the requests that reach it always carry a Principal in
+their context.
+*/
+func Authentication(keys *keyring.KeyRing, denylist auth.Denylist) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			principal, err := authenticate(r, keys, denylist)
+			if err != nil {
+				models.ErrorEncoder(r.Context(), err, rw)
+				return
 			}
-			next.ServeHTTP(rw, r.WithContext(ctx))
-			return
-		}
 
-		payload := token.Claims.(*auth.MailxClaims)
-		ctx = context.WithValue(r.Context(), UserIDKey, payload.ID)
-		next.ServeHTTP(rw, r.WithContext(ctx))
-	})
+			next.ServeHTTP(rw, r.WithContext(WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+func authenticate(r *http.Request, keys *keyring.KeyRing, denylist auth.Denylist) (*Principal, error) {
+	value, err := auth.TokenFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := auth.ParseJWT(keys, value)
+	if err != nil {
+		return nil, err
+	}
+
+	denied, err := denylist.Contains(r.Context(), claims.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if denied {
+		return nil, models.ErrRevokedToken{}
+	}
+
+	principal := &Principal{
+		UserID:    claims.ID,
+		SessionID: claims.SessionID,
+		Scopes:    strings.Fields(claims.Scope),
+	}
+	// every endpoint behind the middleware reads or changes the mailbox of the user.
+	if !principal.HasScope(auth.ScopeMailbox) {
+		return nil, models.ErrInsufficientScope{}
+	}
+	return principal, nil
 }
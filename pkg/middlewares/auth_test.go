@@ -0,0 +1,154 @@
+package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/orlandorode97/mailx-google-service/auth"
+	"github.com/orlandorode97/mailx-google-service/pkg/keyring"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthentication(t *testing.T) {
+	keys, err := keyring.New("test", keyring.NewHMACKey("test", []byte("signing-key")))
+	assert.Nil(t, err)
+
+	sign := func(id string, scope string, expiresAt time.Time) string {
+		value, err := keys.Sign(auth.MailxClaims{
+			ID:        "1",
+			SessionID: "session-1",
+			Scope:     scope,
+			StandardClaims: jwt.StandardClaims{
+				Id:        id,
+				ExpiresAt: expiresAt.Unix(),
+			},
+		})
+		assert.Nil(t, err)
+		return value
+	}
+
+	denylist := auth.NewMemoryDenylist()
+	assert.Nil(t, denylist.Add(context.Background(), "denied", time.Now().Add(time.Hour)))
+
+	testcases := []struct {
+		name              string
+		authorization     string
+		cookie            string
+		expectedStatus    int
+		expectedErr       error
+		expectedPrincipal *Principal
+	}{
+		{
+			name:           "success - cookie token.",
+			cookie:         sign("jwt-1", auth.ScopeMailbox, time.Now().Add(time.Hour)),
+			expectedStatus: http.StatusOK,
+			expectedPrincipal: &Principal{
+				UserID:    "1",
+				SessionID: "session-1",
+				Scopes:    []string{auth.ScopeMailbox},
+			},
+		},
+		{
+			name:           "success - bearer token.",
+			authorization:  "Bearer " + sign("jwt-1", auth.ScopeMailbox, time.Now().Add(time.Hour)),
+			cookie:         "ignored",
+			expectedStatus: http.StatusOK,
+			expectedPrincipal: &Principal{
+				UserID:    "1",
+				SessionID: "session-1",
+				Scopes:    []string{auth.ScopeMailbox},
+			},
+		},
+		{
+			name:           "failure - missing token.",
+			expectedStatus: http.StatusUnauthorized,
+			expectedErr:    models.ErrInvalidCookie{},
+		},
+		{
+			name:           "failure - expired token.",
+			cookie:         sign("jwt-1", auth.ScopeMailbox, time.Now().Add(-time.Hour)),
+			expectedStatus: http.StatusUnauthorized,
+			expectedErr:    models.ErrExpiredToken{},
+		},
+		{
+			name:           "failure - malformed token.",
+			authorization:  "Bearer not-a-jwt",
+			expectedStatus: http.StatusUnauthorized,
+			expectedErr:    models.ErrMalformedToken{},
+		},
+		{
+			name:           "failure - denied token.",
+			cookie:         sign("denied", auth.ScopeMailbox, time.Now().Add(time.Hour)),
+			expectedStatus: http.StatusUnauthorized,
+			expectedErr:    models.ErrRevokedToken{},
+		},
+		{
+			name:           "failure - token without the mailbox scope.",
+			cookie:         sign("jwt-1", "", time.Now().Add(time.Hour)),
+			expectedStatus: http.StatusForbidden,
+			expectedErr:    models.ErrInsufficientScope{},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			var principal *Principal
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				principal, _ = PrincipalFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/labels/", nil)
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			if test.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "mailx_google_auth", Value: test.cookie})
+			}
+
+			w := httptest.NewRecorder()
+			Authentication(keys, denylist)(next).ServeHTTP(w, req)
+			assert.Equal(t, test.expectedStatus, w.Code)
+			assert.Equal(t, test.expectedPrincipal, principal)
+
+			if test.expectedErr != nil {
+				var body map[string]string
+				assert.Nil(t, json.NewDecoder(w.Body).Decode(&body))
+				assert.Equal(t, test.expectedErr.Error(), body["error"])
+			}
+		})
+	}
+}
+
+func TestPrincipalHasScope(t *testing.T) {
+	principal := &Principal{Scopes: []string{auth.ScopeMailbox}}
+	assert.True(t, principal.HasScope(auth.ScopeMailbox))
+	assert.False(t, principal.HasScope("admin"))
+}
+
+func TestUserIDFromRequest(t *testing.T) {
+	t.Run("success - user id of the principal is returned.", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/messages", nil)
+		req = req.WithContext(WithPrincipal(req.Context(), &Principal{UserID: "1"}))
+		userID, err := UserIDFromRequest(req)
+		assert.Nil(t, err)
+		assert.Equal(t, "1", userID)
+	})
+
+	t.Run("failure - request without a principal is unauthorized.", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/messages", nil)
+		userID, err := UserIDFromRequest(req)
+		assert.Equal(t, models.ErrUnauthenticated{}, err)
+		assert.Empty(t, userID)
+
+		w := httptest.NewRecorder()
+		models.ErrorEncoder(context.Background(), err, w)
+		assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
+	})
+}
@@ -0,0 +1,29 @@
+package models
+
+// Address represents a mailbox as a display name and email address pair.
+type Address struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email"`
+}
+
+// Attachment represents a file attached to an outgoing message. Data is sent as standard base64 in JSON.
+type Attachment struct {
+	Filename string `json:"filename"`
+	MimeType string `json:"mime_type,omitempty"`
+	Data     []byte `json:"data"`
+}
+
+// OutgoingMessage holds the structured input used to compose a new email.
+type OutgoingMessage struct {
+	To          []Address    `json:"to"`
+	Cc          []Address    `json:"cc,omitempty"`
+	Bcc         []Address    `json:"bcc,omitempty"`
+	Subject     string       `json:"subject"`
+	Text        string       `json:"text,omitempty"`
+	HTML        string       `json:"html,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	// InReplyTo, References and ThreadID keep replies and forwards in the original conversation.
+	InReplyTo  string `json:"-"`
+	References string `json:"-"`
+	ThreadID   string `json:"-"`
+}
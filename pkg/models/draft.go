@@ -0,0 +1,14 @@
+package models
+
+// Draft represents an unsent message. Listed drafts only carry the id and thread of their message.
+type Draft struct {
+	ID      string   `json:"id"`
+	Message *Message `json:"message"`
+}
+
+// DraftsPage represents a page of drafts and the token to request the next one.
+type DraftsPage struct {
+	Drafts             []*Draft `json:"drafts"`
+	NextPageToken      string   `json:"next_page_token,omitempty"`
+	ResultSizeEstimate int64    `json:"result_size_estimate"`
+}
@@ -25,6 +25,20 @@ func (e ErrInvalidToken) Error() string {
 	return "the token is invalid."
 }
 
+// ErrUnauthenticated is returned when a request did not go through the authentication middleware.
+type ErrUnauthenticated struct{}
+
+func (e ErrUnauthenticated) Error() string {
+	return "the request is not authenticated."
+}
+
+// ErrInsufficientScope is returned when the json web token was not issued with the scope an endpoint requires.
+type ErrInsufficientScope struct{}
+
+func (e ErrInsufficientScope) Error() string {
+	return "the token does not grant access to this resource."
+}
+
 type ErrExpiredToken struct{}
 
 func (e ErrExpiredToken) Error() string {
@@ -49,6 +63,27 @@ func (e ErrInvalidSignature) Error() string {
 	return "the token has an invalid signature."
 }
 
+// ErrRevokedToken is returned when the json web token was invalidated before its expiration, e.g. on logout.
+type ErrRevokedToken struct{}
+
+func (e ErrRevokedToken) Error() string {
+	return "the token has been revoked."
+}
+
+// ErrInvalidSession is returned when the refresh token is unknown, expired, revoked or was already rotated.
+type ErrInvalidSession struct{}
+
+func (e ErrInvalidSession) Error() string {
+	return "the session is expired, revoked or invalid."
+}
+
+// ErrInvalidState is returned when the oauth callback state is missing, expired, already used or issued to another browser.
+type ErrInvalidState struct{}
+
+func (e ErrInvalidState) Error() string {
+	return "the oauth state is missing, expired or invalid."
+}
+
 type ErrInvalidData struct {
 	Field string
 }
@@ -57,6 +92,13 @@ func (e ErrInvalidData) Error() string {
 	return fmt.Sprintf("the field `%s` is invalid", e.Field)
 }
 
+// ErrHistoryExpired is returned when gmail no longer keeps the history since the requested history id.
+type ErrHistoryExpired struct{}
+
+func (e ErrHistoryExpired) Error() string {
+	return "the history id is too old, a full resync is required."
+}
+
 // ErrorEncoder encodes incoming errors to write the corresponding http status header.
 func ErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
 
@@ -65,7 +107,11 @@ func ErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
 		w.WriteHeader(http.StatusBadRequest)
 	case ErrAuthUrl:
 		w.WriteHeader(http.StatusServiceUnavailable)
-	case ErrInvalidSignature, ErrInvalidToken, ErrExpiredToken, ErrMalformedToken, ErrInactiveToken, ErrInvalidCookie:
+	case ErrHistoryExpired:
+		w.WriteHeader(http.StatusGone)
+	case ErrInsufficientScope:
+		w.WriteHeader(http.StatusForbidden)
+	case ErrInvalidSignature, ErrInvalidToken, ErrExpiredToken, ErrMalformedToken, ErrInactiveToken, ErrInvalidCookie, ErrInvalidState, ErrRevokedToken, ErrInvalidSession, ErrUnauthenticated:
 		w.WriteHeader(http.StatusUnauthorized)
 	default:
 		w.WriteHeader(http.StatusInternalServerError)
@@ -0,0 +1,20 @@
+package models
+
+// MessageChange identifies a message affected by a mailbox change. LabelIDs holds the current labels of added messages,
+// and the labels that were added or removed for label changes.
+type MessageChange struct {
+	ID       string   `json:"id"`
+	ThreadID string   `json:"threadId"`
+	LabelIDs []string `json:"labelIds,omitempty"`
+}
+
+// MailboxChanges represents the changes of a mailbox since a history id, in the order gmail recorded them.
+type MailboxChanges struct {
+	// HistoryID is the current history id of the mailbox, clients send it back on their next sync.
+	HistoryID       uint64           `json:"historyId"`
+	NextPageToken   string           `json:"next_page_token,omitempty"`
+	MessagesAdded   []*MessageChange `json:"messagesAdded"`
+	MessagesDeleted []*MessageChange `json:"messagesDeleted"`
+	LabelsAdded     []*MessageChange `json:"labelsAdded"`
+	LabelsRemoved   []*MessageChange `json:"labelsRemoved"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "google.golang.org/api/gmail/v1"
+
+// Label holds the fields a mailx client is able to set on a gmail label.
+type Label struct {
+	Name                  string      `json:"name"`
+	LabelListVisibility   string      `json:"label_list_visibility"`
+	MessageListVisibility string      `json:"message_list_visibility"`
+	Color                 *LabelColor `json:"color,omitempty"`
+}
+
+type LabelColor struct {
+	BackgroundColor string `json:"background_color"`
+	TextColor       string `json:"text_color"`
+}
+
+// Validate checks the label visibility values accepted by the gmail api.
+func (l *Label) Validate() error {
+	switch l.LabelListVisibility {
+	case "", "labelShow", "labelShowIfUnread", "labelHide":
+	default:
+		return ErrInvalidData{Field: "label_list_visibility"}
+	}
+
+	switch l.MessageListVisibility {
+	case "", "show", "hide":
+	default:
+		return ErrInvalidData{Field: "message_list_visibility"}
+	}
+
+	if l.Color != nil && (l.Color.BackgroundColor == "" || l.Color.TextColor == "") {
+		return ErrInvalidData{Field: "color"}
+	}
+
+	return nil
+}
+
+// GmailLabel converts the label into a *gmail.Label ready to be sent to the gmail api.
+func (l *Label) GmailLabel() *gmail.Label {
+	label := &gmail.Label{
+		Name:                  l.Name,
+		LabelListVisibility:   l.LabelListVisibility,
+		MessageListVisibility: l.MessageListVisibility,
+	}
+
+	if l.Color != nil {
+		label.Color = &gmail.LabelColor{
+			BackgroundColor: l.Color.BackgroundColor,
+			TextColor:       l.Color.TextColor,
+		}
+	}
+
+	return label
+}
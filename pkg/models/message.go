@@ -1,15 +1,92 @@
 package models
 
-import "google.golang.org/api/gmail/v1"
+import (
+	"io"
+	"time"
+
+	"google.golang.org/api/gmail/v1"
+)
 
 type Message struct {
-	ID           string             `json:"id"`
-	HistoryID    uint64             `json:"historyId"`
-	InternalDate int64              `json:"internalDate"`
-	LabelIDS     []string           `json:"labelIds"`
-	Payload      *gmail.MessagePart `json:"payload"`
-	SizeEstimate int64              `json:"sizeEstimate"`
-	Snippet      string             `json:"snippet"`
-	ThreadID     string             `json:"threadId"`
-	HTML         string             `json:"html"`
+	ID              string     `json:"id"`
+	HistoryID       uint64     `json:"historyId"`
+	InternalDate    int64      `json:"internalDate"`
+	LabelIDS        []string   `json:"labelIds"`
+	From            *Address   `json:"from,omitempty"`
+	To              []Address  `json:"to,omitempty"`
+	Cc              []Address  `json:"cc,omitempty"`
+	Bcc             []Address  `json:"bcc,omitempty"`
+	ReplyTo         []Address  `json:"replyTo,omitempty"`
+	Subject         string     `json:"subject"`
+	Date            *time.Time `json:"date,omitempty"`
+	MessageID       string     `json:"messageId,omitempty"`
+	InReplyTo       string     `json:"inReplyTo,omitempty"`
+	ListUnsubscribe []string   `json:"listUnsubscribe,omitempty"`
+	// Payload is only returned with the full message format.
+	Payload      *gmail.MessagePart   `json:"payload,omitempty"`
+	SizeEstimate int64                `json:"sizeEstimate"`
+	Snippet      string               `json:"snippet"`
+	ThreadID     string               `json:"threadId"`
+	HTML         string               `json:"html"`
+	Text         string               `json:"text"`
+	Attachments  []*MessageAttachment `json:"attachments"`
+}
+
+// MessageFormat selects how much of a message is returned to the clients.
+type MessageFormat string
+
+const (
+	// MessageFormatFull returns the parsed fields along with the raw gmail payload.
+	MessageFormatFull MessageFormat = "full"
+	// MessageFormatMetadata returns the parsed fields without the gmail payload.
+	MessageFormatMetadata MessageFormat = "metadata"
+)
+
+// ParseMessageFormat validates the format requested by the client, full is the default.
+func ParseMessageFormat(value string) (MessageFormat, error) {
+	switch format := MessageFormat(value); format {
+	case "":
+		return MessageFormatFull, nil
+	case MessageFormatFull, MessageFormatMetadata:
+		return format, nil
+	}
+	return "", ErrInvalidData{Field: "format"}
+}
+
+// Apply drops the fields of the message that are not part of the format, it returns the same message.
+func (f MessageFormat) Apply(message *Message) *Message {
+	if message != nil && f == MessageFormatMetadata {
+		message.Payload = nil
+	}
+	return message
+}
+
+// MessageAttachment describes an attachment of a message without its content.
+type MessageAttachment struct {
+	AttachmentID string `json:"attachmentId"`
+	PartID       string `json:"partId"`
+	Filename     string `json:"filename"`
+	MimeType     string `json:"mimeType"`
+	Size         int64  `json:"size"`
+}
+
+// AttachmentContent pairs an attachment description with a reader of its decoded bytes.
+type AttachmentContent struct {
+	MessageAttachment
+	Content io.Reader `json:"-"`
+}
+
+// MessagesPage represents a page of hydrated messages and the token to request the next one.
+// The messages that could not be fetched are left out of Messages and described in Errors.
+type MessagesPage struct {
+	Messages           []*Message     `json:"messages"`
+	Errors             []MessageError `json:"errors,omitempty"`
+	NextPageToken      string         `json:"next_page_token,omitempty"`
+	ResultSizeEstimate int64          `json:"result_size_estimate"`
+}
+
+// MessageError describes why a message of a page could not be fetched.
+type MessageError struct {
+	MessageID string `json:"message_id"`
+	Error     string `json:"error"`
 }
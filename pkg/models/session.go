@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Session is a refresh token issued to a user. Rotating a refresh token creates a new session of the same family,
+// the family groups every session created since the user signed in.
+type Session struct {
+	ID        string
+	FamilyID  string
+	UserID    string
+	TokenHash string
+	ExpiresAt time.Time
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+}
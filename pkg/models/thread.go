@@ -0,0 +1,16 @@
+package models
+
+// Thread represents a conversation. Listed threads only carry their summary, messages are set when a single thread is requested.
+type Thread struct {
+	ID        string     `json:"id"`
+	HistoryID uint64     `json:"historyId"`
+	Snippet   string     `json:"snippet"`
+	Messages  []*Message `json:"messages,omitempty"`
+}
+
+// ThreadsPage represents a page of threads and the token to request the next one.
+type ThreadsPage struct {
+	Threads            []*Thread `json:"threads"`
+	NextPageToken      string    `json:"next_page_token,omitempty"`
+	ResultSizeEstimate int64     `json:"result_size_estimate"`
+}
@@ -9,4 +9,5 @@ type Token struct {
 	TokenExpiration time.Time
 	RefreshToken    string
 	TokenType       string
+	IsActive        bool
 }
@@ -6,22 +6,26 @@ import (
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
+	"github.com/orlandorode97/mailx-google-service/pkg/envelope"
 	"github.com/orlandorode97/mailx-google-service/pkg/models"
 	"github.com/orlandorode97/mailx-google-service/pkg/repos"
 	"golang.org/x/oauth2"
 )
 
 type repository struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	cipher *envelope.Cipher
 }
 
-func New(db *sqlx.DB) repos.Repository {
+// New builds the postgres repository, the oauth tokens are encrypted at rest with the cipher.
+func New(db *sqlx.DB, cipher *envelope.Cipher) repos.Repository {
 	if err := db.Ping(); err != nil {
 		return nil
 	}
 
 	return &repository{
-		db: db,
+		db:     db,
+		cipher: cipher,
 	}
 }
 
@@ -73,7 +77,7 @@ func (r *repository) GetUserByID(ctx context.Context, ID string) (*models.User,
 func (r *repository) GetTokenByUserId(ctx context.Context, ID string) (*models.Token, error) {
 	var token models.Token
 	query, args, err := sq.
-		Select("id", "google_id", "access_token", "token_expiration", "refresh_token", "token_type").
+		Select("id", "google_id", "access_token", "token_expiration", "refresh_token", "token_type", "is_active").
 		From("auth_users").
 		Where(sq.Eq{"google_id": ID}).
 		PlaceholderFormat(sq.Dollar).
@@ -89,18 +93,32 @@ func (r *repository) GetTokenByUserId(ctx context.Context, ID string) (*models.T
 		&token.TokenExpiration,
 		&token.RefreshToken,
 		&token.TokenType,
+		&token.IsActive,
 	); err != nil {
 		return nil, err
 	}
 
+	if token.AccessToken, err = r.cipher.Decrypt(token.AccessToken, token.UserID); err != nil {
+		return nil, err
+	}
+
+	if token.RefreshToken, err = r.cipher.Decrypt(token.RefreshToken, token.UserID); err != nil {
+		return nil, err
+	}
+
 	return &token, nil
 }
 
 func (r *repository) SaveAccessToken(ctx context.Context, ID string, token *oauth2.Token) error {
+	accessToken, refreshToken, err := r.encryptToken(ID, token)
+	if err != nil {
+		return err
+	}
+
 	query, args, err := sq.
 		Insert("auth_users").
 		Columns("google_id", "access_token", "token_expiration", "refresh_token", "token_type").
-		Values(ID, token.AccessToken, token.Expiry, token.RefreshToken, token.TokenType).
+		Values(ID, accessToken, token.Expiry, refreshToken, token.TokenType).
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
 	if err != nil {
@@ -114,12 +132,69 @@ func (r *repository) SaveAccessToken(ctx context.Context, ID string, token *oaut
 	return nil
 }
 
+// UpdateAccessToken saves a refreshed token, the token of a user that logged out or whose grant was revoked stays inactive.
 func (r *repository) UpdateAccessToken(ctx context.Context, ID string, token *oauth2.Token) error {
-	query, args, err := sq.
+	return r.updateAccessToken(ctx, ID, token, false)
+}
+
+// ReactivateAccessToken saves the token of a user that signed in again and marks it as active.
+func (r *repository) ReactivateAccessToken(ctx context.Context, ID string, token *oauth2.Token) error {
+	return r.updateAccessToken(ctx, ID, token, true)
+}
+
+func (r *repository) updateAccessToken(ctx context.Context, ID string, token *oauth2.Token, activate bool) error {
+	accessToken, refreshToken, err := r.encryptToken(ID, token)
+	if err != nil {
+		return err
+	}
+
+	update := sq.
 		Update("auth_users").
-		Set("access_token", token.AccessToken).
+		Set("access_token", accessToken).
 		Set("token_expiration", token.Expiry).
-		Set("refresh_token", token.RefreshToken).
+		Set("refresh_token", refreshToken).
+		Set("updated_at", time.Now()).
+		Where(sq.Eq{"google_id": ID})
+	if activate {
+		update = update.Set("is_active", true)
+	} else {
+		update = update.Where(sq.Eq{"is_active": true})
+	}
+
+	query, args, err := update.
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	if _, err = r.db.DB.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// encryptToken encrypts the access and refresh tokens bound to the user, a token copied into the row
+// of another user cannot be decrypted.
+func (r *repository) encryptToken(ID string, token *oauth2.Token) (string, string, error) {
+	accessToken, err := r.cipher.Encrypt(token.AccessToken, ID)
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshToken, err := r.cipher.Encrypt(token.RefreshToken, ID)
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+func (r *repository) DeactivateToken(ctx context.Context, ID string) error {
+	query, args, err := sq.
+		Update("auth_users").
+		Set("is_active", false).
 		Set("updated_at", time.Now()).
 		Where(sq.Eq{"google_id": ID}).
 		PlaceholderFormat(sq.Dollar).
@@ -134,3 +209,116 @@ func (r *repository) UpdateAccessToken(ctx context.Context, ID string, token *oa
 
 	return nil
 }
+
+func (r *repository) CreateSession(ctx context.Context, session *models.Session) error {
+	query, args, err := sq.
+		Insert("sessions").
+		Columns("id", "family_id", "google_id", "token_hash", "expires_at").
+		Values(session.ID, session.FamilyID, session.UserID, session.TokenHash, session.ExpiresAt).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	if _, err = r.db.DB.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *repository) GetSessionByTokenHash(ctx context.Context, hash string) (*models.Session, error) {
+	var session models.Session
+	query, args, err := sq.
+		Select("id", "family_id", "google_id", "token_hash", "expires_at", "rotated_at", "revoked_at").
+		From("sessions").
+		Where(sq.Eq{"token_hash": hash}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	row := r.db.DB.QueryRowContext(ctx, query, args...)
+	if err := row.Scan(
+		&session.ID,
+		&session.FamilyID,
+		&session.UserID,
+		&session.TokenHash,
+		&session.ExpiresAt,
+		&session.RotatedAt,
+		&session.RevokedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (r *repository) RotateSession(ctx context.Context, ID string, next *models.Session) error {
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// the conditions make the rotation atomic, only one of two concurrent refreshes rotates the session.
+	query, args, err := sq.
+		Update("sessions").
+		Set("rotated_at", time.Now()).
+		Where(sq.Eq{"id": ID, "rotated_at": nil, "revoked_at": nil}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return models.ErrInvalidSession{}
+	}
+
+	query, args, err = sq.
+		Insert("sessions").
+		Columns("id", "family_id", "google_id", "token_hash", "expires_at").
+		Values(next.ID, next.FamilyID, next.UserID, next.TokenHash, next.ExpiresAt).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *repository) RevokeSessionFamily(ctx context.Context, familyID string) error {
+	query, args, err := sq.
+		Update("sessions").
+		Set("revoked_at", time.Now()).
+		Where(sq.Eq{"family_id": familyID, "revoked_at": nil}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	if _, err = r.db.DB.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	return nil
+}
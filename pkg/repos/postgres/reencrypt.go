@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/orlandorode97/mailx-google-service/pkg/envelope"
+)
+
+/*
+ReencryptTokens encrypts the oauth tokens of every user with the current key encryption key of the cipher,
+the plaintext tokens stored before the encryption and the tokens encrypted with a former key are rewritten.
+Every row is locked and rewritten in its own transaction so the service can keep running meanwhile.
+It returns the number of rewritten rows.
+*/
+func ReencryptTokens(ctx context.Context, db *sqlx.DB, cipher *envelope.Cipher) (int, error) {
+	query, args, err := sq.
+		Select("google_id").
+		From("auth_users").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var IDs []string
+	if err := db.SelectContext(ctx, &IDs, query, args...); err != nil {
+		return 0, err
+	}
+
+	rewritten := 0
+	for _, ID := range IDs {
+		ok, err := reencryptToken(ctx, db, cipher, ID)
+		if err != nil {
+			return rewritten, err
+		}
+
+		if ok {
+			rewritten++
+		}
+	}
+
+	return rewritten, nil
+}
+
+// reencryptToken rewrites the tokens of the user when they are not encrypted with the current key.
+func reencryptToken(ctx context.Context, db *sqlx.DB, cipher *envelope.Cipher, ID string) (bool, error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	query, args, err := sq.
+		Select("access_token", "refresh_token").
+		From("auth_users").
+		Where(sq.Eq{"google_id": ID}).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	var accessToken, refreshToken string
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&accessToken, &refreshToken); err != nil {
+		return false, err
+	}
+
+	if !cipher.NeedsRotation(accessToken) && !cipher.NeedsRotation(refreshToken) {
+		return false, nil
+	}
+
+	values := make([]string, 0, 2)
+	for _, value := range []string{accessToken, refreshToken} {
+		plaintext, err := cipher.Decrypt(value, ID)
+		if err != nil {
+			return false, err
+		}
+
+		encrypted, err := cipher.Encrypt(plaintext, ID)
+		if err != nil {
+			return false, err
+		}
+		values = append(values, encrypted)
+	}
+
+	query, args, err = sq.
+		Update("auth_users").
+		Set("access_token", values[0]).
+		Set("refresh_token", values[1]).
+		Set("updated_at", time.Now()).
+		Where(sq.Eq{"google_id": ID}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
@@ -28,10 +28,26 @@ type UserRepository interface {
 type TokenRepository interface {
 	GetTokenByUserId(context.Context, string) (*models.Token, error)
 	SaveAccessToken(context.Context, string, *oauth2.Token) error
+	// UpdateAccessToken saves a refreshed token, it never reactivates an inactive token.
 	UpdateAccessToken(context.Context, string, *oauth2.Token) error
+	// ReactivateAccessToken saves the token of a user that signed in again and marks it as active.
+	ReactivateAccessToken(context.Context, string, *oauth2.Token) error
+	// DeactivateToken marks the token of the user as inactive, e.g. when google revokes its refresh token.
+	DeactivateToken(context.Context, string) error
+}
+
+type SessionRepository interface {
+	CreateSession(context.Context, *models.Session) error
+	GetSessionByTokenHash(context.Context, string) (*models.Session, error)
+	// RotateSession marks the session as rotated and creates the next session of its family. It returns
+	// models.ErrInvalidSession when the session was already rotated or revoked.
+	RotateSession(context.Context, string, *models.Session) error
+	// RevokeSessionFamily revokes every session of the family, the refresh tokens of the family are no longer accepted.
+	RevokeSessionFamily(context.Context, string) error
 }
 
 type Repository interface {
 	UserRepository
 	TokenRepository
+	SessionRepository
 }
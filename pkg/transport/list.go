@@ -0,0 +1,54 @@
+/*
+Package transport holds the request decoding shared by the http transports of the gmail resources.
+*/
+package transport
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+)
+
+// ParseMessageListOptions reads the list options from the `page_size`, `page_token`, `q`, `label_ids` and
+// `include_spam_trash` query values. The limits of page_size are checked by each service.
+func ParseMessageListOptions(query url.Values) (google.MessageListOptions, error) {
+	opts := google.MessageListOptions{
+		PageToken: query.Get("page_token"),
+		Query:     query.Get("q"),
+		LabelIDs:  splitQueryValues(query["label_ids"]),
+	}
+
+	if pageSize := query.Get("page_size"); pageSize != "" {
+		size, err := strconv.ParseInt(pageSize, 10, 64)
+		if err != nil {
+			return google.MessageListOptions{}, models.ErrInvalidData{Field: "page_size"}
+		}
+		opts.MaxResults = size
+	}
+
+	if includeSpamTrash := query.Get("include_spam_trash"); includeSpamTrash != "" {
+		include, err := strconv.ParseBool(includeSpamTrash)
+		if err != nil {
+			return google.MessageListOptions{}, models.ErrInvalidData{Field: "include_spam_trash"}
+		}
+		opts.IncludeSpamTrash = include
+	}
+
+	return opts, nil
+}
+
+// splitQueryValues accepts both repeated and comma separated query values such as `label_ids=INBOX,UNREAD`.
+func splitQueryValues(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				result = append(result, v)
+			}
+		}
+	}
+	return result
+}
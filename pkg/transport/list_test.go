@@ -0,0 +1,55 @@
+package transport
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMessageListOptions(t *testing.T) {
+	testcases := []struct {
+		name         string
+		query        string
+		expectedOpts google.MessageListOptions
+		expectedErr  error
+	}{
+		{
+			name:  "success - query values are parsed into list options.",
+			query: "q=is:unread&label_ids=INBOX,%20CATEGORY_PERSONAL,&label_ids=UNREAD&page_size=20&page_token=abc&include_spam_trash=true",
+			expectedOpts: google.MessageListOptions{
+				MaxResults:       20,
+				PageToken:        "abc",
+				Query:            "is:unread",
+				LabelIDs:         []string{"INBOX", "CATEGORY_PERSONAL", "UNREAD"},
+				IncludeSpamTrash: true,
+			},
+		},
+		{
+			name: "success - no query values.",
+		},
+		{
+			name:        "failure - page size is not a number.",
+			query:       "page_size=ten",
+			expectedErr: models.ErrInvalidData{Field: "page_size"},
+		},
+		{
+			name:        "failure - include spam trash is not a boolean.",
+			query:       "include_spam_trash=maybe",
+			expectedErr: models.ErrInvalidData{Field: "include_spam_trash"},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			query, err := url.ParseQuery(test.query)
+			assert.Nil(t, err)
+
+			opts, err := ParseMessageListOptions(query)
+			assert.Equal(t, test.expectedErr, err)
+			assert.Equal(t, test.expectedOpts, opts)
+		})
+	}
+}
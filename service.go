@@ -2,11 +2,14 @@ package mailx
 
 import (
 	"context"
-	"sync"
+	"net/http"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
 	"github.com/orlandorode97/mailx-google-service/pkg/repos"
+	"github.com/spf13/viper"
 	"golang.org/x/oauth2"
 	"google.golang.org/api/gmail/v1"
 	"google.golang.org/api/option"
@@ -14,6 +17,10 @@ import (
 
 const (
 	UserInfoUrl = "https://www.googleapis.com/oauth2/v2/userinfo?access_token="
+	// recreateTimeout bounds the shared recreation of a gmail service, it does not depend on the ctx of any caller.
+	recreateTimeout = 30 * time.Second
+	// defaultCallTimeout bounds every gmail call unless GMAIL_CALL_TIMEOUT is set.
+	defaultCallTimeout = 30 * time.Second
 )
 
 /*
@@ -24,20 +31,25 @@ const (
 */
 
 type Creator interface {
-	// CreateGmailService returns a new gmail service instance.
-	CreateGmailService(*oauth2.Token) (google.Service, error)
-	// RecreateGmailService returns a new gmail service when a service is not attached to a user
+	// CreateGmailService returns a new gmail service instance for the user. Refreshed tokens are saved back to the repository.
+	CreateGmailService(context.Context, string, *oauth2.Token) (google.Service, error)
+	// RecreateGmailService returns a new gmail service when a service is not attached to a user.
+	// The concurrent recreations for the same user share a single token read and gmail service.
 	RecreateGmailService(context.Context, string) (google.Service, error)
 }
 
 type Getter interface {
 	// GetGmailService returns a pointer of gmail service.
 	GetGmailService(string) google.Service
+	// CacheStats returns the hit, miss and eviction counters of the gmail services cache.
+	CacheStats() CacheStats
 }
 
 type Setter interface {
 	// AddGmailServiceByID creates a new entry of a pointer gmail service by google user ID.
 	AddGmailServiceByID(string, google.Service) google.Service
+	// Evict drops the gmail service of the user, e.g. after logout.
+	Evict(string)
 }
 
 type Service interface {
@@ -51,42 +63,71 @@ type service struct {
 	//`config` keeps the oauth2 configuration that holds google_client_id, client_secret, and other needed things.
 	config *oauth2.Config
 	repo   repos.TokenRepository
-	//Map that holds google user ID as a key and stores a pointer gmail service.
-	gmailSvcs map[string]google.Service
-	mu        sync.Mutex
+	// gmailSvcs holds the gmail services by google user ID, it is bounded by GMAIL_CLIENT_CACHE_SIZE and
+	// GMAIL_CLIENT_CACHE_IDLE_TTL.
+	gmailSvcs *clientCache
+	// recreations collapses the concurrent recreations of the gmail service of a user.
+	recreations flightGroup
+	// callTimeout is the deadline of every gmail call including its retries, the ctx of the call may set an earlier one.
+	callTimeout time.Duration
+	// retrier retries the gmail calls rejected by the rate limits of gmail.
+	retrier *google.Retrier
 }
 
 func New(logger log.Logger, repo repos.TokenRepository, config *oauth2.Config) Service {
+	callTimeout := viper.GetDuration("GMAIL_CALL_TIMEOUT")
+	if callTimeout <= 0 {
+		callTimeout = defaultCallTimeout
+	}
+
+	retrier := google.NewRetrier()
+	if attempts := viper.GetInt("GMAIL_RETRY_MAX_ATTEMPTS"); attempts > 0 {
+		retrier.MaxAttempts = attempts
+	}
+	retrier.Timeout = callTimeout
+
 	return &service{
-		logger:    logger,
-		config:    config,
-		repo:      repo,
-		gmailSvcs: make(map[string]google.Service),
+		logger:      logger,
+		config:      config,
+		repo:        repo,
+		gmailSvcs:   newClientCache(viper.GetInt("GMAIL_CLIENT_CACHE_SIZE"), viper.GetDuration("GMAIL_CLIENT_CACHE_IDLE_TTL")),
+		callTimeout: callTimeout,
+		retrier:     retrier,
 	}
 }
 
 func (s *service) AddGmailServiceByID(userID string, gmailSvc google.Service) google.Service {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.gmailSvcs[userID] = gmailSvc
+	s.gmailSvcs.add(userID, gmailSvc)
 	return gmailSvc
 }
 
-func (s *service) GetGmailService(userID string) google.Service {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (s *service) Evict(userID string) {
+	s.gmailSvcs.evict(userID)
+}
 
-	gmailSvc, ok := s.gmailSvcs[userID]
-	if ok {
-		return gmailSvc
-	}
+func (s *service) GetGmailService(userID string) google.Service {
+	return s.gmailSvcs.get(userID)
+}
 
-	return nil
+func (s *service) CacheStats() CacheStats {
+	return s.gmailSvcs.stats()
 }
 
-func (s *service) CreateGmailService(token *oauth2.Token) (google.Service, error) {
-	ctx := context.Background()
-	gmailSvc, err := gmail.NewService(ctx, option.WithTokenSource(s.config.TokenSource(ctx, token)))
+/*
+CreateGmailService builds the gmail service of the user, ctx only bounds the creation. The gmail service outlives
+the request creating it, so the token refreshes are detached from ctx and every gmail call is bound to the ctx
+given to the google wrappers, which the retrier bounds by callTimeout.
+*/
+func (s *service) CreateGmailService(ctx context.Context, userID string, token *oauth2.Token) (google.Service, error) {
+	source := s.config.TokenSource(context.Background(), token)
+	client := &http.Client{
+		Transport: &oauth2.Transport{
+			Source: newPersistingTokenSource(s.logger, s.repo, userID, token, source),
+		},
+		Timeout: s.callTimeout,
+	}
+
+	gmailSvc, err := gmail.NewService(ctx, option.WithHTTPClient(client))
 	if err != nil {
 		s.logger.Log(
 			"message", "could not create gmail service",
@@ -102,12 +143,24 @@ func (s *service) CreateGmailService(token *oauth2.Token) (google.Service, error
 }
 
 func (s *service) RecreateGmailService(ctx context.Context, userID string) (google.Service, error) {
+	return s.recreations.do(ctx, userID, func() (google.Service, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), recreateTimeout)
+		defer cancel()
+		return s.recreateGmailService(ctx, userID)
+	})
+}
+
+func (s *service) recreateGmailService(ctx context.Context, userID string) (google.Service, error) {
 	token, err := s.repo.GetTokenByUserId(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	svc, err := s.CreateGmailService(&oauth2.Token{
+	if !token.IsActive {
+		return nil, models.ErrInactiveToken{}
+	}
+
+	svc, err := s.CreateGmailService(ctx, userID, &oauth2.Token{
 		AccessToken:  token.AccessToken,
 		RefreshToken: token.RefreshToken,
 		TokenType:    token.TokenType,
@@ -123,12 +176,13 @@ func (s *service) RecreateGmailService(ctx context.Context, userID string) (goog
 
 func (s *service) hydrateServices(svc *gmail.Service) google.Service {
 	return &google.GmailService{
-		Users:    svc.Users,
-		Labels:   google.NewLabelsService(svc.Users.Labels),
-		Messages: google.NewMessagesService(svc.Users.Messages),
-		Drafts:   &google.DraftsService{},
-		History:  &google.HistoryService{},
-		Settings: &google.SettingsService{},
-		Threads:  &google.ThreadsService{},
+		Users:       svc.Users,
+		Labels:      google.NewLabelsService(svc.Users.Labels, s.retrier),
+		Messages:    google.NewMessagesService(svc.Users.Messages, s.retrier),
+		Attachments: google.NewAttachmentsService(svc.Users.Messages.Attachments, s.retrier),
+		Drafts:      google.NewDraftsService(svc.Users.Drafts, s.retrier),
+		History:     google.NewHistoryService(svc.Users.History, s.retrier),
+		Settings:    &google.SettingsService{},
+		Threads:     google.NewThreadsService(svc.Users.Threads, s.retrier),
 	}
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -39,15 +41,25 @@ func (db MockDB) UpdateAccessToken(ctx context.Context, ID string, token *oauth2
 	return args.Error(0)
 }
 
+func (db MockDB) ReactivateAccessToken(ctx context.Context, ID string, token *oauth2.Token) error {
+	args := db.Called(ctx, ID, token)
+	return args.Error(0)
+}
+
+func (db MockDB) DeactivateToken(ctx context.Context, ID string) error {
+	args := db.Called(ctx, ID)
+	return args.Error(0)
+}
+
 func TestAddGmailServiceByID(t *testing.T) {
 	t.Run("success - gmail is added by user ID", func(t *testing.T) {
 		logger := log.NewLogfmtLogger(os.Stdout)
 		svc := &service{
 			logger:    logger,
-			gmailSvcs: make(map[string]google.Service),
+			gmailSvcs: newClientCache(0, 0),
 		}
 		svc.AddGmailServiceByID("1", &google.GmailService{})
-		assert.Len(t, svc.gmailSvcs, 1)
+		assert.Equal(t, 1, svc.CacheStats().Size)
 	})
 }
 
@@ -85,7 +97,10 @@ func TestGetGmailService(t *testing.T) {
 			logger := log.NewLogfmtLogger(os.Stdout)
 			svc := &service{
 				logger:    logger,
-				gmailSvcs: test.gmailSvcs,
+				gmailSvcs: newClientCache(0, 0),
+			}
+			for userID, gmailSvc := range test.gmailSvcs {
+				svc.AddGmailServiceByID(userID, gmailSvc)
 			}
 			gmailSvc := svc.GetGmailService(test.userID)
 			test.assertGmailSvc(t, gmailSvc)
@@ -93,6 +108,17 @@ func TestGetGmailService(t *testing.T) {
 	}
 }
 
+func TestEvict(t *testing.T) {
+	svc := &service{
+		logger:    log.NewLogfmtLogger(os.Stdout),
+		gmailSvcs: newClientCache(0, 0),
+	}
+	svc.AddGmailServiceByID("1", &google.GmailService{})
+	svc.Evict("1")
+	assert.Nil(t, svc.GetGmailService("1"))
+	assert.Equal(t, CacheStats{Misses: 1, Evictions: 1}, svc.CacheStats())
+}
+
 func TestCreateGmailService(t *testing.T) {
 	t.Run("success - gmail service is created", func(t *testing.T) {
 		svc := &service{
@@ -100,7 +126,7 @@ func TestCreateGmailService(t *testing.T) {
 			config: &oauth2.Config{},
 		}
 		token := &oauth2.Token{}
-		gmailSvc, err := svc.CreateGmailService(token)
+		gmailSvc, err := svc.CreateGmailService(context.Background(), "1", token)
 		assert.Nil(t, err)
 		assert.NotNil(t, gmailSvc)
 	})
@@ -126,10 +152,22 @@ func TestRecreateGmailService(t *testing.T) {
 				RefreshToken:    "refresh-token",
 				TokenType:       "Bearer",
 				TokenExpiration: time.Now(),
+				IsActive:        true,
 			},
 			assertErr: assert.Nil,
 			assertSvc: assert.NotNil,
 		},
+		{
+			name:   "failure - token was deactivated",
+			ctx:    context.Background(),
+			userID: "1",
+			token: &models.Token{
+				AccessToken:  "access-token",
+				RefreshToken: "refresh-token",
+			},
+			assertErr: assert.NotNil,
+			assertSvc: assert.Nil,
+		},
 		{
 			name:      "failure - get token by id fails",
 			ctx:       context.Background(),
@@ -148,6 +186,7 @@ func TestRecreateGmailService(t *testing.T) {
 				RefreshToken:    "refresh-token",
 				TokenType:       "Bearer",
 				TokenExpiration: time.Now(),
+				IsActive:        true,
 			},
 			errSvc:    errors.New("token no valid"),
 			assertErr: assert.Nil,
@@ -158,7 +197,7 @@ func TestRecreateGmailService(t *testing.T) {
 	for _, test := range testcases {
 		t.Run(test.name, func(t *testing.T) {
 			db := MockDB{}
-			db.On("GetTokenByUserId", test.ctx, test.userID).Return(test.token, test.errToken)
+			db.On("GetTokenByUserId", mock.Anything, test.userID).Return(test.token, test.errToken)
 			logger := log.NewLogfmtLogger(os.Stdout)
 			svc := New(logger, db, nil)
 			newSvc, err := svc.RecreateGmailService(test.ctx, test.userID)
@@ -167,3 +206,84 @@ func TestRecreateGmailService(t *testing.T) {
 		})
 	}
 }
+
+func TestRecreateGmailServiceConcurrently(t *testing.T) {
+	testcases := []struct {
+		name     string
+		token    *models.Token
+		errToken error
+	}{
+		{
+			name: "success - concurrent callers share the gmail service",
+			token: &models.Token{
+				AccessToken:     "access-token",
+				RefreshToken:    "refresh-token",
+				TokenType:       "Bearer",
+				TokenExpiration: time.Now(),
+				IsActive:        true,
+			},
+		},
+		{
+			name:     "failure - concurrent callers share the error",
+			token:    nil,
+			errToken: errors.New("database is not online"),
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			const callers = 100
+			var calls, joined int32
+			db := MockDB{}
+			db.On("GetTokenByUserId", mock.Anything, "1").
+				Run(func(args mock.Arguments) {
+					atomic.AddInt32(&calls, 1)
+					// the repository call is held until every caller joined the flight.
+					for atomic.LoadInt32(&joined) < callers {
+						time.Sleep(time.Millisecond)
+					}
+				}).
+				Return(test.token, test.errToken)
+
+			svc := New(log.NewLogfmtLogger(os.Stdout), db, &oauth2.Config{}).(*service)
+			svc.recreations.joined = func(string) {
+				atomic.AddInt32(&joined, 1)
+			}
+
+			gmailSvcs := make([]google.Service, callers)
+			errs := make([]error, callers)
+			var wg sync.WaitGroup
+			for i := 0; i < callers; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					gmailSvcs[i], errs[i] = svc.RecreateGmailService(context.Background(), "1")
+				}(i)
+			}
+			wg.Wait()
+
+			assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+			for i := 0; i < callers; i++ {
+				assert.Equal(t, gmailSvcs[0], gmailSvcs[i])
+				assert.Equal(t, test.errToken, errs[i])
+			}
+		})
+	}
+}
+
+func TestRecreateGmailServiceCanceledCaller(t *testing.T) {
+	release := make(chan struct{})
+	db := MockDB{}
+	db.On("GetTokenByUserId", mock.Anything, "1").
+		Run(func(args mock.Arguments) { <-release }).
+		Return((*models.Token)(nil), errors.New("database is not online"))
+
+	svc := New(log.NewLogfmtLogger(os.Stdout), db, &oauth2.Config{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	gmailSvc, err := svc.RecreateGmailService(ctx, "1")
+	assert.Nil(t, gmailSvc)
+	assert.Equal(t, context.Canceled, err)
+	close(release)
+}
@@ -0,0 +1,87 @@
+package threads
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+)
+
+type Endpoints struct {
+	GetThreadsEndpoint    endpoint.Endpoint
+	GetThreadByIDEndpoint endpoint.Endpoint
+}
+
+func MakeEndpoints(s Service) Endpoints {
+	return Endpoints{
+		GetThreadsEndpoint:    MakeGetThreads(s),
+		GetThreadByIDEndpoint: MakeGetThreadByID(s),
+	}
+}
+
+func MakeGetThreads(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(getThreadsRequest)
+		page, err := s.GetThreads(ctx, req.UserID, req.Options)
+		if err != nil {
+			return getThreadsResponse{
+				Err: err,
+			}, nil
+		}
+		return getThreadsResponse{
+			Threads:            page.Threads,
+			NextPageToken:      page.NextPageToken,
+			ResultSizeEstimate: page.ResultSizeEstimate,
+		}, nil
+	}
+}
+
+func MakeGetThreadByID(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(getThreadByIDRequest)
+		thread, err := s.GetThreadByID(ctx, req.UserID, req.ThreadID)
+		if err != nil {
+			return getThreadByIDResponse{
+				Err: err,
+			}, nil
+		}
+		for _, message := range thread.Messages {
+			req.Format.Apply(message)
+		}
+		return getThreadByIDResponse{
+			Thread: thread,
+		}, nil
+	}
+}
+
+type getThreadsRequest struct {
+	UserID  string
+	Options google.MessageListOptions
+}
+
+type getThreadsResponse struct {
+	Threads            []*models.Thread `json:"threads"`
+	NextPageToken      string           `json:"next_page_token,omitempty"`
+	ResultSizeEstimate int64            `json:"result_size_estimate"`
+	Err                error            `json:"error,omitempty"`
+}
+
+func (g getThreadsResponse) error() error {
+	return g.Err
+}
+
+type getThreadByIDRequest struct {
+	UserID   string
+	ThreadID string
+	Format   models.MessageFormat
+}
+
+type getThreadByIDResponse struct {
+	Thread *models.Thread `json:"thread"`
+	Err    error          `json:"error,omitempty"`
+}
+
+func (g getThreadByIDResponse) error() error {
+	return g.Err
+}
@@ -0,0 +1,138 @@
+package threads
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/go-kit/log"
+	"github.com/orlandorode97/mailx-google-service"
+	"github.com/orlandorode97/mailx-google-service/messages"
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/orlandorode97/mailx-google-service/pkg/repos"
+)
+
+const (
+	// threadsLimit is the default page size when a client does not request one.
+	threadsLimit int64 = 10
+	// maxThreadsLimit caps the page size to the maximum accepted by gmail.
+	maxThreadsLimit int64 = 500
+)
+
+type Service interface {
+	// GetThreads lists the summary of the threads matching the options.
+	GetThreads(context.Context, string, google.MessageListOptions) (*models.ThreadsPage, error)
+	// GetThreadByID returns a whole conversation with its messages ordered by date.
+	GetThreadByID(context.Context, string, string) (*models.Thread, error)
+}
+
+type service struct {
+	logger   log.Logger
+	repo     repos.Repository
+	mailxSvc mailx.Service
+}
+
+func New(logger log.Logger, repo repos.Repository, mailx mailx.Service) Service {
+	return &service{
+		logger:   logger,
+		repo:     repo,
+		mailxSvc: mailx,
+	}
+}
+
+// threadService returns the threads service attached to the user, recreating the gmail service when it is missing.
+func (s *service) threadService(ctx context.Context, userID string) (google.Threader, error) {
+	svc := s.mailxSvc.GetGmailService(userID)
+	if svc == nil || (reflect.ValueOf(svc).Kind() == reflect.Ptr && reflect.ValueOf(svc).IsNil()) {
+		var err error
+		svc, err = s.mailxSvc.RecreateGmailService(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return svc.GetThreadsService(), nil
+}
+
+func (s *service) GetThreads(ctx context.Context, userID string, opts google.MessageListOptions) (*models.ThreadsPage, error) {
+	switch {
+	case opts.MaxResults < 0 || opts.MaxResults > maxThreadsLimit:
+		return nil, models.ErrInvalidData{Field: "page_size"}
+	case opts.MaxResults == 0:
+		opts.MaxResults = threadsLimit
+	}
+
+	svc, err := s.threadService(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	threadsResp, err := svc.List(ctx, userID, opts).Do()
+	if err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error getting threads for user=%s", userID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return nil, err
+	}
+
+	threads := make([]*models.Thread, 0, len(threadsResp.Threads))
+	for _, thread := range threadsResp.Threads {
+		threads = append(threads, &models.Thread{
+			ID:        thread.Id,
+			HistoryID: thread.HistoryId,
+			Snippet:   thread.Snippet,
+		})
+	}
+
+	return &models.ThreadsPage{
+		Threads:            threads,
+		NextPageToken:      threadsResp.NextPageToken,
+		ResultSizeEstimate: threadsResp.ResultSizeEstimate,
+	}, nil
+}
+
+func (s *service) GetThreadByID(ctx context.Context, userID string, threadID string) (*models.Thread, error) {
+	svc, err := s.threadService(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	thread, err := svc.Get(ctx, userID, threadID).Do()
+	if err != nil {
+		s.logger.Log(
+			"message", fmt.Sprintf("error getting thread=%s for user=%s", threadID, userID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return nil, err
+	}
+
+	threadMessages := make([]*models.Message, 0, len(thread.Messages))
+	for _, message := range thread.Messages {
+		msg, err := messages.HydrateMessage(message)
+		if err != nil {
+			s.logger.Log(
+				"message", fmt.Sprintf("error hydrating message=%s of thread=%s for user=%s", message.Id, threadID, userID),
+				"error", err.Error(),
+				"severity", "ERROR",
+			)
+			return nil, err
+		}
+		threadMessages = append(threadMessages, msg)
+	}
+
+	// internalDate is the time gmail received the message, it is reliable even when the Date header is missing or forged.
+	sort.SliceStable(threadMessages, func(i, j int) bool {
+		return threadMessages[i].InternalDate < threadMessages[j].InternalDate
+	})
+
+	return &models.Thread{
+		ID:        thread.Id,
+		HistoryID: thread.HistoryId,
+		Snippet:   thread.Snippet,
+		Messages:  threadMessages,
+	}, nil
+}
@@ -0,0 +1,325 @@
+package threads
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/orlandorode97/mailx-google-service"
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+type MockGmailService struct {
+	mock.Mock
+}
+
+func (m *MockGmailService) GetLabelsService() google.Labeler {
+	args := m.Called()
+	return args.Get(0).(google.Labeler)
+}
+
+func (m *MockGmailService) GetMessagesService() google.Messenger {
+	args := m.Called()
+	return args.Get(0).(google.Messenger)
+}
+
+func (m *MockGmailService) GetAttachmentsService() google.Attacher {
+	args := m.Called()
+	return args.Get(0).(google.Attacher)
+}
+
+func (m *MockGmailService) GetThreadsService() google.Threader {
+	args := m.Called()
+	return args.Get(0).(google.Threader)
+}
+
+func (m *MockGmailService) GetDraftsService() google.Drafter {
+	args := m.Called()
+	return args.Get(0).(google.Drafter)
+}
+
+func (m *MockGmailService) GetHistoryService() google.Historian {
+	args := m.Called()
+	return args.Get(0).(google.Historian)
+}
+
+type MockMailxService struct {
+	mock.Mock
+}
+
+func (m *MockMailxService) GetGmailService(userID string) google.Service {
+	args := m.Called(userID)
+	return args.Get(0).(google.Service)
+}
+
+func (m *MockMailxService) CreateGmailService(ctx context.Context, userID string, token *oauth2.Token) (google.Service, error) {
+	args := m.Called(ctx, userID, token)
+	return args.Get(0).(google.Service), args.Error(1)
+}
+
+func (m *MockMailxService) AddGmailServiceByID(ID string, gmailSvc google.Service) google.Service {
+	args := m.Called(ID, gmailSvc)
+	return args.Get(0).(google.Service)
+}
+
+func (m *MockMailxService) Evict(ID string) {
+	m.Called(ID)
+}
+
+func (m *MockMailxService) CacheStats() mailx.CacheStats {
+	args := m.Called()
+	return args.Get(0).(mailx.CacheStats)
+}
+
+func (m *MockMailxService) RecreateGmailService(ctx context.Context, ID string) (google.Service, error) {
+	args := m.Called(ctx, ID)
+	return args.Get(0).(google.Service), args.Error(1)
+}
+
+type MockThreader struct {
+	mock.Mock
+}
+
+func (m *MockThreader) Delete(ctx context.Context, userID string, threadID string) google.ThreaderClient {
+	args := m.Called(ctx, userID, threadID)
+	return args.Get(0).(google.ThreaderClient)
+}
+
+func (m *MockThreader) Get(ctx context.Context, userID string, threadID string) google.ThreaderClientResp {
+	args := m.Called(ctx, userID, threadID)
+	return args.Get(0).(google.ThreaderClientResp)
+}
+
+func (m *MockThreader) List(ctx context.Context, userID string, opts google.MessageListOptions) google.ThreaderClientList {
+	args := m.Called(ctx, userID, opts)
+	return args.Get(0).(google.ThreaderClientList)
+}
+
+func (m *MockThreader) Modify(ctx context.Context, userID string, threadID string, req *gmail.ModifyThreadRequest) google.ThreaderClientResp {
+	args := m.Called(ctx, userID, threadID, req)
+	return args.Get(0).(google.ThreaderClientResp)
+}
+
+func (m *MockThreader) Trash(ctx context.Context, userID string, threadID string) google.ThreaderClientResp {
+	args := m.Called(ctx, userID, threadID)
+	return args.Get(0).(google.ThreaderClientResp)
+}
+
+func (m *MockThreader) Untrash(ctx context.Context, userID string, threadID string) google.ThreaderClientResp {
+	args := m.Called(ctx, userID, threadID)
+	return args.Get(0).(google.ThreaderClientResp)
+}
+
+type MockThreaderClientResp struct {
+	mock.Mock
+}
+
+func (m *MockThreaderClientResp) Do(opts ...googleapi.CallOption) (*gmail.Thread, error) {
+	args := m.Called(opts)
+	return args.Get(0).(*gmail.Thread), args.Error(1)
+}
+
+type MockThreaderClientList struct {
+	mock.Mock
+}
+
+func (m *MockThreaderClientList) Do(opts ...googleapi.CallOption) (*gmail.ListThreadsResponse, error) {
+	args := m.Called(opts)
+	return args.Get(0).(*gmail.ListThreadsResponse), args.Error(1)
+}
+
+func threadMessage(id string, internalDate int64, subject string) *gmail.Message {
+	return &gmail.Message{
+		Id:           id,
+		ThreadId:     "THREAD_1",
+		InternalDate: internalDate,
+		Payload: &gmail.MessagePart{
+			MimeType: "text/plain",
+			Headers: []*gmail.MessagePartHeader{
+				{Name: "Subject", Value: subject},
+			},
+			Body: &gmail.MessagePartBody{Data: base64.RawURLEncoding.EncodeToString([]byte("body of " + id))},
+		},
+	}
+}
+
+func TestGetThreads(t *testing.T) {
+	testcases := []struct {
+		name            string
+		userID          string
+		opts            google.MessageListOptions
+		expectedOpts    google.MessageListOptions
+		listResponse    *gmail.ListThreadsResponse
+		errList         error
+		assertErr       func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+		expectedThreads *models.ThreadsPage
+	}{
+		{
+			name:   "success - page token and query are sent to the gmail api.",
+			userID: "1",
+			opts: google.MessageListOptions{
+				MaxResults: 25,
+				PageToken:  "page-2",
+				Query:      "is:unread",
+			},
+			expectedOpts: google.MessageListOptions{
+				MaxResults: 25,
+				PageToken:  "page-2",
+				Query:      "is:unread",
+			},
+			listResponse: &gmail.ListThreadsResponse{
+				Threads: []*gmail.Thread{
+					{Id: "THREAD_1", HistoryId: 10, Snippet: "hello"},
+				},
+				NextPageToken:      "page-3",
+				ResultSizeEstimate: 40,
+			},
+			assertErr: assert.Nil,
+			expectedThreads: &models.ThreadsPage{
+				Threads:            []*models.Thread{{ID: "THREAD_1", HistoryID: 10, Snippet: "hello"}},
+				NextPageToken:      "page-3",
+				ResultSizeEstimate: 40,
+			},
+		},
+		{
+			name:   "success - default page size is used when it is not requested.",
+			userID: "1",
+			expectedOpts: google.MessageListOptions{
+				MaxResults: threadsLimit,
+			},
+			listResponse:    &gmail.ListThreadsResponse{},
+			assertErr:       assert.Nil,
+			expectedThreads: &models.ThreadsPage{Threads: []*models.Thread{}},
+		},
+		{
+			name:   "failure - page size exceeds the limit.",
+			userID: "1",
+			opts: google.MessageListOptions{
+				MaxResults: maxThreadsLimit + 1,
+			},
+			listResponse: &gmail.ListThreadsResponse{},
+			assertErr:    assert.NotNil,
+		},
+		{
+			name:   "failure - gmail threads service responds an error.",
+			userID: "1",
+			expectedOpts: google.MessageListOptions{
+				MaxResults: threadsLimit,
+			},
+			listResponse: &gmail.ListThreadsResponse{},
+			errList:      errors.New("invalid page token"),
+			assertErr:    assert.NotNil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			logger := log.NewLogfmtLogger(os.Stdin)
+			mockGmailService := &MockGmailService{}
+			mailxSvc := &MockMailxService{}
+			threader := &MockThreader{}
+			call := &MockThreaderClientList{}
+
+			call.On("Do", []googleapi.CallOption(nil)).Return(test.listResponse, test.errList)
+			threader.On("List", mock.Anything, test.userID, test.expectedOpts).Return(call)
+			mockGmailService.On("GetThreadsService").Return(threader)
+			mailxSvc.On("GetGmailService", test.userID).Return(mockGmailService)
+
+			threadsSvc := New(logger, nil, mailxSvc)
+			page, err := threadsSvc.GetThreads(context.Background(), test.userID, test.opts)
+			test.assertErr(t, err)
+			assert.Equal(t, test.expectedThreads, page)
+		})
+	}
+}
+
+func TestGetThreadByID(t *testing.T) {
+	testcases := []struct {
+		name        string
+		userID      string
+		threadID    string
+		thread      *gmail.Thread
+		errGet      error
+		recreate    bool
+		assertErr   func(t assert.TestingT, object interface{}, msgAndArgs ...interface{}) bool
+		expectedIDs []string
+	}{
+		{
+			name:     "success - messages are hydrated and ordered by date.",
+			userID:   "1",
+			threadID: "THREAD_1",
+			thread: &gmail.Thread{
+				Id:        "THREAD_1",
+				HistoryId: 20,
+				Messages: []*gmail.Message{
+					threadMessage("MESSAGE_3", 3000, "Re: hello"),
+					threadMessage("MESSAGE_1", 1000, "hello"),
+					threadMessage("MESSAGE_2", 2000, "Re: hello"),
+				},
+			},
+			assertErr:   assert.Nil,
+			expectedIDs: []string{"MESSAGE_1", "MESSAGE_2", "MESSAGE_3"},
+		},
+		{
+			name:        "success - gmail service is recreated when it is not cached.",
+			userID:      "1",
+			threadID:    "THREAD_1",
+			thread:      &gmail.Thread{Id: "THREAD_1", Messages: []*gmail.Message{threadMessage("MESSAGE_1", 1000, "hello")}},
+			recreate:    true,
+			assertErr:   assert.Nil,
+			expectedIDs: []string{"MESSAGE_1"},
+		},
+		{
+			name:      "failure - gmail threads service responds an error.",
+			userID:    "1",
+			threadID:  "THREAD_1",
+			thread:    &gmail.Thread{},
+			errGet:    errors.New("not found"),
+			assertErr: assert.NotNil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			logger := log.NewLogfmtLogger(os.Stdin)
+			mockGmailService := &MockGmailService{}
+			mailxSvc := &MockMailxService{}
+			threader := &MockThreader{}
+			call := &MockThreaderClientResp{}
+
+			call.On("Do", []googleapi.CallOption(nil)).Return(test.thread, test.errGet)
+			threader.On("Get", mock.Anything, test.userID, test.threadID).Return(call)
+			mockGmailService.On("GetThreadsService").Return(threader)
+			if test.recreate {
+				mailxSvc.On("GetGmailService", test.userID).Return((*MockGmailService)(nil))
+				mailxSvc.On("RecreateGmailService", mock.Anything, test.userID).Return(mockGmailService, nil)
+			} else {
+				mailxSvc.On("GetGmailService", test.userID).Return(mockGmailService)
+			}
+
+			threadsSvc := New(logger, nil, mailxSvc)
+			thread, err := threadsSvc.GetThreadByID(context.Background(), test.userID, test.threadID)
+			test.assertErr(t, err)
+			if err != nil {
+				return
+			}
+
+			ids := make([]string, 0, len(thread.Messages))
+			for _, message := range thread.Messages {
+				ids = append(ids, message.ID)
+				assert.Equal(t, "body of "+message.ID, message.Text)
+			}
+			assert.Equal(t, test.expectedIDs, ids)
+			assert.Equal(t, "hello", thread.Messages[0].Subject)
+		})
+	}
+}
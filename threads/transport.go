@@ -0,0 +1,94 @@
+package threads
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/orlandorode97/mailx-google-service/pkg/middlewares"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/orlandorode97/mailx-google-service/pkg/transport"
+)
+
+func MakeHandler(threadsService Service, logger log.Logger) http.Handler {
+	r := mux.NewRouter()
+
+	e := MakeEndpoints(threadsService)
+	options := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(models.ErrorEncoder),
+	}
+	r.Methods(http.MethodGet).
+		Path("/threads/").
+		Handler(kithttp.NewServer(
+			e.GetThreadsEndpoint,
+			decodeThreadsRequest,
+			encodeThreadResponse,
+			options...,
+		))
+
+	r.Methods(http.MethodGet).
+		Path("/threads/{thread_id:[0-9a-zA-Z]+}").
+		Handler(kithttp.NewServer(
+			e.GetThreadByIDEndpoint,
+			decodeThreadByIDRequest,
+			encodeThreadResponse,
+			options...,
+		))
+
+	return r
+}
+
+func decodeThreadsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	userID, err := middlewares.UserIDFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := transport.ParseMessageListOptions(r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	return getThreadsRequest{
+		UserID:  userID,
+		Options: opts,
+	}, nil
+}
+
+func decodeThreadByIDRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	userID, err := middlewares.UserIDFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	threadID := mux.Vars(r)["thread_id"]
+	if threadID == "" {
+		return nil, models.ErrInvalidData{Field: "thread_id"}
+	}
+
+	format, err := models.ParseMessageFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		return nil, err
+	}
+
+	return getThreadByIDRequest{
+		UserID:   userID,
+		ThreadID: threadID,
+		Format:   format,
+	}, nil
+}
+
+func encodeThreadResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	if e, ok := response.(errorer); ok && e.error() != nil {
+		return e.error()
+	}
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type errorer interface {
+	error() error
+}
@@ -0,0 +1,84 @@
+package threads
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/orlandorode97/mailx-google-service/pkg/google"
+	"github.com/orlandorode97/mailx-google-service/pkg/middlewares"
+	"github.com/orlandorode97/mailx-google-service/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeThreadsRequest(t *testing.T) {
+	testcases := []struct {
+		name         string
+		url          string
+		expectedOpts google.MessageListOptions
+		expectedErr  error
+	}{
+		{
+			name: "success - query parameters are decoded into list options.",
+			url:  "/threads/?q=is:unread&label_ids=INBOX,UNREAD&page_size=20&page_token=abc",
+			expectedOpts: google.MessageListOptions{
+				MaxResults: 20,
+				PageToken:  "abc",
+				Query:      "is:unread",
+				LabelIDs:   []string{"INBOX", "UNREAD"},
+			},
+		},
+		{
+			name:        "failure - page size is not a number.",
+			url:         "/threads/?page_size=ten",
+			expectedErr: models.ErrInvalidData{Field: "page_size"},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.url, nil)
+			req = req.WithContext(middlewares.WithPrincipal(req.Context(), &middlewares.Principal{UserID: "1"}))
+			request, err := decodeThreadsRequest(context.Background(), req)
+			assert.Equal(t, test.expectedErr, err)
+			if err == nil {
+				assert.Equal(t, getThreadsRequest{UserID: "1", Options: test.expectedOpts}, request)
+			}
+		})
+	}
+}
+
+func TestDecodeThreadByIDRequest(t *testing.T) {
+	testcases := []struct {
+		name            string
+		url             string
+		threadID        string
+		expectedRequest interface{}
+		expectedErr     error
+	}{
+		{
+			name:            "success - thread id and format are decoded.",
+			url:             "/threads/THREAD_1?format=metadata",
+			threadID:        "THREAD_1",
+			expectedRequest: getThreadByIDRequest{UserID: "1", ThreadID: "THREAD_1", Format: models.MessageFormatMetadata},
+		},
+		{
+			name:        "failure - missing thread id.",
+			url:         "/threads/",
+			expectedErr: models.ErrInvalidData{Field: "thread_id"},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.url, nil)
+			req = req.WithContext(middlewares.WithPrincipal(req.Context(), &middlewares.Principal{UserID: "1"}))
+			req = mux.SetURLVars(req, map[string]string{"thread_id": test.threadID})
+			request, err := decodeThreadByIDRequest(context.Background(), req)
+			assert.Equal(t, test.expectedErr, err)
+			assert.Equal(t, test.expectedRequest, request)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package mailx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/orlandorode97/mailx-google-service/pkg/repos"
+	"golang.org/x/oauth2"
+)
+
+// invalidGrant is the oauth2 error code google answers when the refresh token was revoked or expired.
+const invalidGrant = "invalid_grant"
+
+// persistingTokenSource wraps the oauth2 token source of a user and writes every refreshed token back
+// to the repository, so a recreated gmail service does not start from a stale access token.
+type persistingTokenSource struct {
+	logger log.Logger
+	repo   repos.TokenRepository
+	userID string
+	source oauth2.TokenSource
+
+	mu sync.Mutex
+	// last is the latest token known by the repository.
+	last *oauth2.Token
+}
+
+func newPersistingTokenSource(logger log.Logger, repo repos.TokenRepository, userID string, token *oauth2.Token, source oauth2.TokenSource) oauth2.TokenSource {
+	return &persistingTokenSource{
+		logger: logger,
+		repo:   repo,
+		userID: userID,
+		source: source,
+		last:   token,
+	}
+}
+
+func (p *persistingTokenSource) Token() (*oauth2.Token, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ctx := context.Background()
+	token, err := p.source.Token()
+	if err != nil {
+		if isInvalidGrant(err) {
+			p.logger.Log(
+				"message", fmt.Sprintf("refresh token revoked for user=%s, deactivating token", p.userID),
+				"error", err.Error(),
+				"severity", "ERROR",
+			)
+			if err := p.repo.DeactivateToken(ctx, p.userID); err != nil {
+				p.logger.Log(
+					"message", fmt.Sprintf("error deactivating token for user=%s", p.userID),
+					"error", err.Error(),
+					"severity", "ERROR",
+				)
+			}
+		}
+		return nil, err
+	}
+
+	if p.last != nil && p.last.AccessToken == token.AccessToken && p.last.Expiry.Equal(token.Expiry) {
+		return token, nil
+	}
+
+	// a failed write must not break the current request, the token is saved again on the next refresh.
+	if err := p.repo.UpdateAccessToken(ctx, p.userID, token); err != nil {
+		p.logger.Log(
+			"message", fmt.Sprintf("error saving refreshed token for user=%s", p.userID),
+			"error", err.Error(),
+			"severity", "ERROR",
+		)
+		return token, nil
+	}
+
+	p.logger.Log(
+		"message", fmt.Sprintf("refreshed token saved for user=%s", p.userID),
+		"severity", "INFO",
+	)
+	p.last = token
+	return token, nil
+}
+
+func isInvalidGrant(err error) bool {
+	var retrieveErr *oauth2.RetrieveError
+	if !errors.As(err, &retrieveErr) {
+		return false
+	}
+
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(retrieveErr.Body, &body); err != nil {
+		return false
+	}
+	return body.Error == invalidGrant
+}
@@ -0,0 +1,116 @@
+package mailx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/oauth2"
+)
+
+// newTokenServer returns a fake oauth2 token endpoint answering the given status and body, and counting the requests.
+func newTokenServer(t *testing.T, status int, body string) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "refresh_token" {
+			t.Errorf("unexpected token request: %v", r.Form)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func newTestTokenSource(db MockDB, tokenURL string, token *oauth2.Token) oauth2.TokenSource {
+	config := &oauth2.Config{
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		Endpoint:     oauth2.Endpoint{TokenURL: tokenURL, AuthStyle: oauth2.AuthStyleInParams},
+	}
+	return newPersistingTokenSource(log.NewLogfmtLogger(os.Stdin), db, "1", token, config.TokenSource(context.Background(), token))
+}
+
+func TestPersistingTokenSource(t *testing.T) {
+	expired := &oauth2.Token{
+		AccessToken:  "expired-access-token",
+		RefreshToken: "refresh-token",
+		TokenType:    "Bearer",
+		Expiry:       time.Now().Add(-time.Hour),
+	}
+
+	t.Run("success - refreshed token is saved once.", func(t *testing.T) {
+		server, requests := newTokenServer(t, http.StatusOK, `{"access_token":"new-access-token","token_type":"Bearer","expires_in":3600}`)
+
+		db := MockDB{}
+		db.On("UpdateAccessToken", mock.Anything, "1", mock.MatchedBy(func(token *oauth2.Token) bool {
+			return token.AccessToken == "new-access-token" && token.RefreshToken == "refresh-token" && token.Expiry.After(time.Now())
+		})).Return(nil).Once()
+
+		source := newTestTokenSource(db, server.URL, expired)
+		for i := 0; i < 3; i++ {
+			token, err := source.Token()
+			assert.Nil(t, err)
+			assert.Equal(t, "new-access-token", token.AccessToken)
+		}
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+		db.AssertExpectations(t)
+	})
+
+	t.Run("success - valid token is not saved.", func(t *testing.T) {
+		server, requests := newTokenServer(t, http.StatusOK, `{}`)
+
+		db := MockDB{}
+		valid := &oauth2.Token{AccessToken: "access-token", RefreshToken: "refresh-token", Expiry: time.Now().Add(time.Hour)}
+		token, err := newTestTokenSource(db, server.URL, valid).Token()
+		assert.Nil(t, err)
+		assert.Equal(t, "access-token", token.AccessToken)
+
+		assert.Equal(t, int32(0), atomic.LoadInt32(requests))
+		db.AssertNotCalled(t, "UpdateAccessToken", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("success - token is returned even if it cannot be saved.", func(t *testing.T) {
+		server, _ := newTokenServer(t, http.StatusOK, `{"access_token":"new-access-token","token_type":"Bearer","expires_in":3600}`)
+
+		db := MockDB{}
+		db.On("UpdateAccessToken", mock.Anything, "1", mock.Anything).Return(errors.New("database is not online"))
+
+		token, err := newTestTokenSource(db, server.URL, expired).Token()
+		assert.Nil(t, err)
+		assert.Equal(t, "new-access-token", token.AccessToken)
+	})
+
+	t.Run("failure - revoked refresh token deactivates the token.", func(t *testing.T) {
+		server, _ := newTokenServer(t, http.StatusBadRequest, `{"error":"invalid_grant","error_description":"Token has been expired or revoked."}`)
+
+		db := MockDB{}
+		db.On("DeactivateToken", mock.Anything, "1").Return(nil).Once()
+
+		token, err := newTestTokenSource(db, server.URL, expired).Token()
+		assert.NotNil(t, err)
+		assert.Nil(t, token)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("failure - other refresh errors keep the token active.", func(t *testing.T) {
+		server, _ := newTokenServer(t, http.StatusInternalServerError, `{"error":"internal_failure"}`)
+
+		db := MockDB{}
+		_, err := newTestTokenSource(db, server.URL, expired).Token()
+		assert.NotNil(t, err)
+		db.AssertNotCalled(t, "DeactivateToken", mock.Anything, mock.Anything)
+	})
+}
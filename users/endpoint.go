@@ -20,7 +20,7 @@ func MakeEndpoints(s Service) Endpoints {
 func MakeGetUserByIdEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req, _ := request.(getUserByIdRequest)
-		user, err := s.GetUserByID(req.UserID)
+		user, err := s.GetUserByID(ctx, req.UserID)
 		if err != nil {
 			return getUserByIdResponse{Err: err}, nil
 		}
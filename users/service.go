@@ -11,7 +11,7 @@ import (
 )
 
 type Service interface {
-	GetUserByID(string) (*models.User, error)
+	GetUserByID(context.Context, string) (*models.User, error)
 }
 
 type service struct {
@@ -28,8 +28,8 @@ func New(logger log.Logger, repo repos.Repository, mailx mailx.Service) Service
 	}
 }
 
-func (s *service) GetUserByID(ID string) (*models.User, error) {
-	user, err := s.repo.GetUserByID(context.Background(), ID)
+func (s *service) GetUserByID(ctx context.Context, ID string) (*models.User, error) {
+	user, err := s.repo.GetUserByID(ctx, ID)
 	if err != nil {
 		s.logger.Log(
 			"message", fmt.Sprintf("error getting user=%s ", ID),
@@ -29,19 +29,15 @@ func MakeHandler(usersService Service, logger log.Logger) http.Handler {
 			options...,
 		))
 
-	return middlewares.Authentication(r)
+	return r
 }
 
 func decodeUsersRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	if err, ok := r.Context().Value(middlewares.InvalidAuthKey).(error); ok && err != nil {
+	userID, err := middlewares.UserIDFromRequest(r)
+	if err != nil {
 		return nil, err
 	}
 
-	userID, ok := r.Context().Value(middlewares.UserIDKey).(string)
-	if !ok {
-		return nil, models.ErrInvalidData{Field: "user_id"}
-	}
-
 	return getUserByIdRequest{
 		UserID: userID,
 	}, nil